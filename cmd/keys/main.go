@@ -0,0 +1,83 @@
+// Command keys lists and stages the tool keys of the key ring.
+//
+//	go run ./cmd/keys list
+//	go run ./cmd/keys stage [-activates-at 2025-01-31T00:00:00Z] [-alg ES256]
+//
+// It reads the same environment as the server. Running servers pick up a
+// staged key within 30 seconds when they share the ring file.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-lti/lib/config"
+	"go-lti/lib/keys"
+	"os"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cfg, err := config.Setup()
+	if err != nil {
+		fail("Failed to setup config: %v", err)
+	}
+
+	keyManager, err := keys.NewManager(keys.Config{
+		PrivateKeyPath: cfg.KeyConfig.PrivateKeyPath,
+		PublicKeyPath:  cfg.KeyConfig.PublicKeyPath,
+		KeyId:          cfg.LtiConfig.JwkKid,
+		Algorithm:      cfg.LtiConfig.JwkAlg,
+		RingPath:       cfg.KeyConfig.RingPath,
+		RotationDelay:  cfg.KeyConfig.RotationDelay,
+		RetireAfter:    cfg.KeyConfig.RetireAfter,
+	})
+	if err != nil {
+		fail("Failed to load tool keys: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		printJSON(keyManager.Keys())
+	case "stage":
+		flags := flag.NewFlagSet("stage", flag.ExitOnError)
+		activatesAt := flags.String("activates-at", "", "RFC 3339 time the key starts signing, KEY_ROTATION_DELAY from now by default")
+		algorithm := flags.String("alg", "", "RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384 or ES512, the algorithm of the current key by default")
+		flags.Parse(os.Args[2:])
+
+		var at time.Time
+		if *activatesAt != "" {
+			if at, err = time.Parse(time.RFC3339, *activatesAt); err != nil {
+				fail("Invalid -activates-at: %v", err)
+			}
+		}
+
+		key, err := keyManager.Stage(at, jwa.SignatureAlgorithm(*algorithm))
+		if err != nil {
+			fail("Failed to stage key: %v", err)
+		}
+		printJSON(key)
+	default:
+		usage()
+	}
+}
+
+func printJSON(v any) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(data))
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+func usage() {
+	fail("usage: keys list | keys stage [-activates-at RFC3339] [-alg ALG]")
+}
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"go-lti/internal/domain/dto"
+	"go-lti/lib/keys"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+)
+
+type httpHandler struct {
+	keyManager *keys.Manager
+}
+
+func NewHttpHandler(r fiber.Router, token string, keyManager *keys.Manager) {
+	handler := &httpHandler{
+		keyManager: keyManager,
+	}
+
+	r.Use(authorize(token))
+	r.Get("/keys", handler.listKeys)
+	r.Post("/keys", handler.stageKey)
+}
+
+// authorize : Require the admin token as a bearer token, the admin endpoints do not exist without one
+func authorize(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return fiber.ErrNotFound
+		}
+
+		scheme, value, _ := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(value)), []byte(token)) != 1 {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid admin token")
+		}
+
+		return c.Next()
+	}
+}
+
+func (h *httpHandler) listKeys(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(dto.ResponseDto{
+		Message: "Tool keys",
+		Data:    h.keyManager.Keys(),
+	})
+}
+
+func (h *httpHandler) stageKey(c *fiber.Ctx) error {
+	request := new(dto.StageKeyRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(request); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	key, err := h.keyManager.Stage(request.ActivatesAt, jwa.SignatureAlgorithm(request.Algorithm))
+	if errors.Is(err, keys.ErrNoRing) {
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	if errors.Is(err, keys.ErrInvalidActivation) || errors.Is(err, keys.ErrUnsupportedAlgorithm) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ResponseDto{
+		Message: "Tool key staged",
+		Data:    key,
+	})
+}
@@ -3,6 +3,7 @@ package canvas
 import (
 	"go-lti/internal/domain/dto"
 	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/canvasapi"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,6 +19,9 @@ func NewHttpHandler(r fiber.Router, canvasService interfaces.CanvasService) {
 
 	r.Get("/oauth2/login", handler.Oauth2Login)
 	r.Get("/oauth2/redirect", handler.Oauth2Redirect)
+	r.Delete("/oauth2/token", handler.Oauth2Logout)
+	r.Get("/courses", handler.ListCourses)
+	r.Get("/rate_limit", handler.RateLimit)
 }
 
 func (h *httpHandler) Oauth2Login(c *fiber.Ctx) error {
@@ -50,3 +54,52 @@ func (h *httpHandler) Oauth2Redirect(c *fiber.Ctx) error {
 		Data:    userInfo,
 	})
 }
+
+func (h *httpHandler) Oauth2Logout(c *fiber.Ctx) error {
+	if err := h.canvasService.Oauth2Logout(c); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *httpHandler) ListCourses(c *fiber.Ctx) error {
+	user, err := h.canvasService.CurrentUser(c)
+	if err != nil {
+		return err
+	}
+
+	courses, err := h.canvasService.Api(*user).ListCourses(&canvasapi.CoursesOptions{
+		ListOptions:     canvasapi.ListOptions{PerPage: 100},
+		EnrollmentState: "active",
+		Include:         []string{"term", "total_students"},
+	}).Collect(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ResponseDto{
+		Message: "Successfully listed courses",
+		Data:    courses,
+	})
+}
+
+func (h *httpHandler) RateLimit(c *fiber.Ctx) error {
+	user, err := h.canvasService.CurrentUser(c)
+	if err != nil {
+		return err
+	}
+
+	level, ok, err := h.canvasService.Api(*user).RateLimit(c.Context())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "no canvas request was made with this token yet")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ResponseDto{
+		Message: "Successfully read rate limit",
+		Data:    level,
+	})
+}
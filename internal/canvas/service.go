@@ -1,96 +1,389 @@
 package canvas
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go-lti/internal/domain/dto"
 	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/canvasapi"
 	"go-lti/lib/config"
 	"go-lti/lib/httpclient"
+	"go-lti/lib/store"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+// ErrInvalidGrant is returned when Canvas rejects a refresh token, the user revoked the tool or the grant was deleted
+var ErrInvalidGrant = errors.New("canvas rejected the refresh token")
+
 type service struct {
 	cfg        config.AppConfig
 	httpClient httpclient.HttpClient
-	stateCache map[string]string
+	store      store.Store
+	tokens     interfaces.CanvasTokenStore
+	throttle   *canvasapi.Throttle
+
+	// refreshLocks serializes refreshes per user so a refresh token is used once,
+	// a lock is dropped once no request holds or waits for it
+	refreshMu    sync.Mutex
+	refreshLocks map[string]*refreshLock
+}
+
+type refreshLock struct {
+	mu      sync.Mutex
+	waiters int
 }
 
+const (
+	stateKeyPrefix = "canvas:state:"
+	stateTTL       = 10 * time.Minute
+
+	userKeyPrefix  = "canvas:user:"
+	userCookieName = "canvas_user"
+	userCookieTTL  = 30 * 24 * time.Hour
+
+	// refreshSkew refreshes access tokens slightly before they expire
+	refreshSkew = 1 * time.Minute
+)
+
 // Oauth2Login : Redirect user to Canvas Oauth2 login page
 func (s *service) Oauth2Login(c *fiber.Ctx) (string, error) {
 	state := uuid.New().String()
-	canvasDomain := s.cfg.CanvasConfig.Domain
-	clientId := s.cfg.ApiKeyConfig.ClientId
-	redirectUrl := s.cfg.ApiKeyConfig.RedirectUrl
+	query := url.Values{}
+	query.Set("client_id", s.cfg.ApiKeyConfig.ClientId)
+	query.Set("response_type", "code")
+	query.Set("state", state)
+	query.Set("redirect_uri", s.cfg.ApiKeyConfig.RedirectUrl)
 
-	loginUrl := fmt.Sprintf("https://%s/login/oauth2/auth?client_id=%s&response_type=code&state=%s&redirect_uri=%s", canvasDomain, clientId, state, redirectUrl)
+	loginUrl := fmt.Sprintf("https://%s/login/oauth2/auth?%s", s.cfg.CanvasConfig.Domain, query.Encode())
 
-	s.stateCache[state] = state
+	if err := s.store.Set(c.Context(), stateKeyPrefix+state, state, stateTTL); err != nil {
+		return "", err
+	}
 
 	return loginUrl, nil
 }
 
-// Oauth2Redirect : Receive oauth2 callback from Canvas and exchange code for access token
+// Oauth2Redirect : Receive oauth2 callback from Canvas, exchange code for access token and keep the grant of the user
 func (s *service) Oauth2Redirect(c *fiber.Ctx, request *dto.Oauth2RedirectRequest) (*dto.Oauth2ExchangeResponse, error) {
 	if request.Error != "" {
 		return nil, fiber.NewError(fiber.StatusBadRequest, request.ErrorDescription)
 	}
 
-	if request.State != s.stateCache[request.State] {
+	state, err := s.store.Consume(c.Context(), stateKeyPrefix+request.State)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && state != request.State) {
 		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid state")
 	}
-	delete(s.stateCache, request.State)
+	if err != nil {
+		return nil, err
+	}
 
 	canvasDomain := s.cfg.CanvasConfig.Domain
-	grantType := "authorization_code"
-	clientId := s.cfg.ApiKeyConfig.ClientId
-	clientSecret := s.cfg.ApiKeyConfig.Secret
-	redirectUrl := s.cfg.ApiKeyConfig.RedirectUrl
-	code := request.Code
+	tokenUrl := fmt.Sprintf("https://%s/login/oauth2/token", canvasDomain)
 
-	url := fmt.Sprintf("https://%s/login/oauth2/token?grant_type=%s&client_id=%s&client_secret=%s&code=%s&redirect_uri=%s", canvasDomain, grantType, clientId, clientSecret, code, redirectUrl)
+	var exchangeResponse dto.Oauth2ExchangeResponse
+	err = s.httpClient.Call(c.Context(), http.MethodPost, tokenUrl, map[string]string{
+		fiber.HeaderContentType: fiber.MIMEApplicationForm,
+		fiber.HeaderAccept:      fiber.MIMEApplicationJSON,
+	}, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {s.cfg.ApiKeyConfig.ClientId},
+		"client_secret": {s.cfg.ApiKeyConfig.Secret},
+		"code":          {request.Code},
+		"redirect_uri":  {s.cfg.ApiKeyConfig.RedirectUrl},
+	}, &exchangeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	user := dto.CanvasUserRef{
+		Domain: canvasDomain,
+		UserId: exchangeResponse.User.Id,
+	}
+	err = s.tokens.Save(c.Context(), &dto.CanvasUserToken{
+		CanvasUserRef: user,
+		AccessToken:   exchangeResponse.AccessToken,
+		RefreshToken:  exchangeResponse.RefreshToken,
+		TokenType:     exchangeResponse.TokenType,
+		ExpiresAt:     expiresAt(exchangeResponse.ExpiresIn),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setUserCookie(c, user); err != nil {
+		return nil, err
+	}
+
+	return &exchangeResponse, nil
+}
+
+// Oauth2Refresh : Used to get new access token using refresh token. Canvas keeps the refresh token, so none is returned
+func (s *service) Oauth2Refresh(ctx context.Context, refreshToken string) (*dto.Oauth2ExchangeResponse, error) {
+	tokenUrl := fmt.Sprintf("https://%s/login/oauth2/token", s.cfg.CanvasConfig.Domain)
 
 	var exchangeResponse dto.Oauth2ExchangeResponse
-	err := s.httpClient.Call(c.Context(), http.MethodPost, url, map[string]string{
+	err := s.httpClient.Call(ctx, http.MethodPost, tokenUrl, map[string]string{
 		fiber.HeaderContentType: fiber.MIMEApplicationForm,
 		fiber.HeaderAccept:      fiber.MIMEApplicationJSON,
-	}, nil, &exchangeResponse)
+	}, map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     s.cfg.ApiKeyConfig.ClientId,
+		"client_secret": s.cfg.ApiKeyConfig.Secret,
+		"refresh_token": refreshToken,
+	}, &exchangeResponse)
 	if err != nil {
+		var httpErr *httpclient.Error
+		if errors.As(err, &httpErr) && httpErr.Code == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidGrant, httpErr.Message())
+		}
 		return nil, err
 	}
 
 	return &exchangeResponse, nil
 }
 
-// Oauth2Refresh : Used to get new access token using refresh token
-func (s *service) Oauth2Refresh(c *fiber.Ctx, refreshToken string) (string, error) {
-	panic("unimplemented")
+// Oauth2Logout : Delete the grant of the current user at Canvas and forget the user
+func (s *service) Oauth2Logout(c *fiber.Ctx) error {
+	user, err := s.CurrentUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DeleteToken(c.Context(), *user); err != nil {
+		return err
+	}
+
+	if err := s.store.Delete(c.Context(), userKeyPrefix+c.Cookies(userCookieName)); err != nil {
+		return err
+	}
+	s.clearUserCookie(c)
+
+	return nil
 }
 
-// GetUserInfo : Used to get user info from Canvas
-func (s *service) GetUserInfo(c *fiber.Ctx, accessToken string) (any, error) {
-	url := fmt.Sprintf("https://%s/api/v1/users/self", s.cfg.CanvasConfig.Domain)
+// CurrentUser : Return the Canvas user who authorized the tool in this browser
+func (s *service) CurrentUser(c *fiber.Ctx) (*dto.CanvasUserRef, error) {
+	id := c.Cookies(userCookieName)
+	if id == "" {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "canvas authorization required")
+	}
 
-	var userInfo interface{}
-	err := s.httpClient.Call(c.Context(), http.MethodGet, url, map[string]string{
-		fiber.HeaderAuthorization: fmt.Sprintf("Bearer %s", accessToken),
-	}, nil, &userInfo)
+	data, err := s.store.Get(c.Context(), userKeyPrefix+id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "canvas authorization required")
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return userInfo, nil
+	var user dto.CanvasUserRef
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// lockRefresh : Take the refresh lock of a user and return the function releasing it
+func (s *service) lockRefresh(key string) func() {
+	s.refreshMu.Lock()
+	lock, ok := s.refreshLocks[key]
+	if !ok {
+		lock = &refreshLock{}
+		s.refreshLocks[key] = lock
+	}
+	lock.waiters++
+	s.refreshMu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		s.refreshMu.Lock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(s.refreshLocks, key)
+		}
+		s.refreshMu.Unlock()
+	}
+}
+
+// AccessToken : Return a valid access token of a user, refreshing it first when it is about to expire.
+// A refresh token rejected with invalid_grant marks the grant revoked and the user has to authorize again.
+func (s *service) AccessToken(ctx context.Context, user dto.CanvasUserRef) (string, error) {
+	unlock := s.lockRefresh(tokenKey(user))
+	defer unlock()
+
+	token, err := s.tokens.Get(ctx, user)
+	if errors.Is(err, ErrTokenNotFound) {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "canvas authorization required")
+	}
+	if err != nil {
+		return "", err
+	}
+	if token.Revoked {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "canvas authorization was revoked")
+	}
+
+	if token.ExpiresAt.IsZero() || time.Now().Add(refreshSkew).Before(token.ExpiresAt) {
+		return token.AccessToken, nil
+	}
+	if token.RefreshToken == "" {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "canvas access token expired")
+	}
+
+	refreshed, err := s.Oauth2Refresh(ctx, token.RefreshToken)
+	if errors.Is(err, ErrInvalidGrant) {
+		token.Revoked = true
+		if err := s.tokens.Save(ctx, token); err != nil {
+			return "", err
+		}
+		return "", fiber.NewError(fiber.StatusUnauthorized, "canvas authorization was revoked")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh canvas token: %w", err)
+	}
+
+	token.AccessToken = refreshed.AccessToken
+	token.ExpiresAt = expiresAt(refreshed.ExpiresIn)
+	if refreshed.TokenType != "" {
+		token.TokenType = refreshed.TokenType
+	}
+	if refreshed.RefreshToken != "" {
+		token.RefreshToken = refreshed.RefreshToken
+	}
+	if err := s.tokens.Save(ctx, token); err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
+
+// DeleteToken : Delete the grant of a user at Canvas with DELETE /login/oauth2/token and remove the stored token
+func (s *service) DeleteToken(ctx context.Context, user dto.CanvasUserRef) error {
+	token, err := s.tokens.Get(ctx, user)
+	if errors.Is(err, ErrTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !token.Revoked {
+		accessToken, err := s.AccessToken(ctx, user)
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusUnauthorized {
+			// the grant is already gone at Canvas
+			return s.tokens.Delete(ctx, user)
+		}
+		if err != nil {
+			return err
+		}
+
+		tokenUrl := fmt.Sprintf("https://%s/login/oauth2/token", user.Domain)
+		err = s.httpClient.Call(ctx, http.MethodDelete, tokenUrl, map[string]string{
+			fiber.HeaderAuthorization: fmt.Sprintf("Bearer %s", accessToken),
+			fiber.HeaderAccept:        fiber.MIMEApplicationJSON,
+		}, nil, nil)
+		// 401 means Canvas already invalidated the token
+		if err != nil && httpclient.StatusCode(err) != fiber.StatusUnauthorized {
+			return fmt.Errorf("failed to delete canvas token: %w", err)
+		}
+	}
+
+	return s.tokens.Delete(ctx, user)
+}
+
+// Api : Return a Canvas REST client acting as a user, its access token is refreshed when needed
+func (s *service) Api(user dto.CanvasUserRef) *canvasapi.Client {
+	return canvasapi.NewClient(s.httpClient, user.Domain, func(ctx context.Context) (string, error) {
+		return s.AccessToken(ctx, user)
+	}, s.throttle)
+}
+
+// GetUserInfo : Used to get user info from Canvas
+func (s *service) GetUserInfo(c *fiber.Ctx, accessToken string) (*canvasapi.User, error) {
+	return canvasapi.NewClient(s.httpClient, s.cfg.CanvasConfig.Domain, canvasapi.StaticToken(accessToken), s.throttle).GetSelf(c.Context())
+}
+
+// setUserCookie : Private method to remember the authorized user in the browser with an opaque id
+func (s *service) setUserCookie(c *fiber.Ctx, user dto.CanvasUserRef) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Set(c.Context(), userKeyPrefix+id, string(data), userCookieTTL); err != nil {
+		return err
+	}
+
+	cookie := &http.Cookie{
+		Name:        userCookieName,
+		Value:       id,
+		Path:        "/",
+		MaxAge:      int(userCookieTTL.Seconds()),
+		Secure:      true,
+		HttpOnly:    true,
+		SameSite:    http.SameSiteNoneMode,
+		Partitioned: true,
+	}
+	c.Append(fiber.HeaderSetCookie, cookie.String())
+
+	return nil
+}
+
+// clearUserCookie : Private method to remove the user cookie
+func (s *service) clearUserCookie(c *fiber.Ctx) {
+	cookie := &http.Cookie{
+		Name:        userCookieName,
+		Path:        "/",
+		MaxAge:      -1,
+		Secure:      true,
+		HttpOnly:    true,
+		SameSite:    http.SameSiteNoneMode,
+		Partitioned: true,
+	}
+	c.Append(fiber.HeaderSetCookie, cookie.String())
+}
+
+// expiresAt : Expiry of a token valid for expiresIn seconds, the zero time when Canvas gave none
+func expiresAt(expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(time.Duration(expiresIn) * time.Second)
 }
 
 func NewService(
 	cfg config.AppConfig,
 	httpClient httpclient.HttpClient,
+	store store.Store,
+	tokens interfaces.CanvasTokenStore,
+	throttle *canvasapi.Throttle,
 ) interfaces.CanvasService {
 	return &service{
-		cfg:        cfg,
-		httpClient: httpClient,
-		stateCache: make(map[string]string),
+		cfg:          cfg,
+		httpClient:   httpClient,
+		store:        store,
+		tokens:       tokens,
+		throttle:     throttle,
+		refreshLocks: make(map[string]*refreshLock),
 	}
 }
@@ -0,0 +1,144 @@
+package canvas
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/config"
+	"go-lti/lib/store"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrTokenNotFound is returned when the user never authorized the tool or the grant was deleted
+var ErrTokenNotFound = errors.New("canvas token not found")
+
+const tokenKeyPrefix = "canvas:token:"
+
+type tokenStore struct {
+	store store.Store
+	aead  cipher.AEAD
+}
+
+// Get : Return the decrypted token of a user
+func (t *tokenStore) Get(ctx context.Context, user dto.CanvasUserRef) (*dto.CanvasUserToken, error) {
+	key := tokenKey(user)
+	sealed, err := t.store.Get(ctx, key)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a token sealed with another key is as good as missing, the user has to authorize again
+	data, err := t.open(key, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decrypt: %v", ErrTokenNotFound, err)
+	}
+
+	var token dto.CanvasUserToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Save : Encrypt and store the token of a user. Tokens are kept until deleted since refresh tokens do not expire
+func (t *tokenStore) Save(ctx context.Context, token *dto.CanvasUserToken) error {
+	token.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	key := tokenKey(token.CanvasUserRef)
+	sealed, err := t.seal(key, data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt canvas token: %w", err)
+	}
+
+	return t.store.Set(ctx, key, sealed, 0)
+}
+
+// Delete : Remove the token of a user
+func (t *tokenStore) Delete(ctx context.Context, user dto.CanvasUserRef) error {
+	return t.store.Delete(ctx, tokenKey(user))
+}
+
+// seal : Private method to encrypt data with a random nonce prepended to the ciphertext. The store key is
+// authenticated with it, so a token copied under the key of another user does not decrypt.
+func (t *tokenStore) seal(key string, data []byte) (string, error) {
+	nonce := make([]byte, t.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawStdEncoding.EncodeToString(t.aead.Seal(nonce, nonce, data, []byte(key))), nil
+}
+
+// open : Private method to decrypt data sealed for key
+func (t *tokenStore) open(key string, sealed string) ([]byte, error) {
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := t.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return t.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(key))
+}
+
+// tokenKey : Key of a user token, the user id is scoped by the Canvas domain
+func tokenKey(user dto.CanvasUserRef) string {
+	return fmt.Sprintf("%s%s:%d", tokenKeyPrefix, user.Domain, user.UserId)
+}
+
+// NewTokenStore creates a token store encrypting tokens with AES-256-GCM.
+// The key is the base64 encoded CANVAS_API_KEY_TOKEN_KEY, a random key is
+// used when it is not set so stored tokens do not survive a restart.
+func NewTokenStore(
+	cfg config.AppConfig,
+	store store.Store,
+) (interfaces.CanvasTokenStore, error) {
+	key := make([]byte, 32)
+	if cfg.ApiKeyConfig.TokenKey == "" {
+		log.Warn().Msg("CANVAS_API_KEY_TOKEN_KEY is not set, using a random key, stored Canvas tokens will be unreadable after a restart")
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		key, err = base64.StdEncoding.DecodeString(cfg.ApiKeyConfig.TokenKey)
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("CANVAS_API_KEY_TOKEN_KEY must be 32 bytes encoded in base64")
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenStore{
+		store: store,
+		aead:  aead,
+	}, nil
+}
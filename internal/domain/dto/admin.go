@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type StageKeyRequest struct {
+	// ActivatesAt is when the staged key starts signing, after the rotation delay when empty
+	ActivatesAt time.Time `json:"activates_at"`
+	// Algorithm is the JWS algorithm of the staged key, the algorithm of the current key when empty
+	Algorithm string `json:"alg"`
+}
@@ -0,0 +1,92 @@
+package dto
+
+type LtiLineItem struct {
+	Id             string                   `json:"id,omitempty"`
+	ScoreMaximum   float64                  `json:"scoreMaximum"`
+	Label          string                   `json:"label"`
+	ResourceId     string                   `json:"resourceId,omitempty"`
+	ResourceLinkId string                   `json:"resourceLinkId,omitempty"`
+	Tag            string                   `json:"tag,omitempty"`
+	StartDateTime  string                   `json:"startDateTime,omitempty"`
+	EndDateTime    string                   `json:"endDateTime,omitempty"`
+	GradesReleased *bool                    `json:"gradesReleased,omitempty"`
+	SubmissionType *CanvasLtiSubmissionType `json:"https://canvas.instructure.com/lti/submission_type,omitempty"`
+	LaunchUrl      string                   `json:"https://canvas.instructure.com/lti/launch_url,omitempty"`
+}
+
+// CanvasLtiSubmissionType is the Canvas extension that makes a line item an external tool assignment
+type CanvasLtiSubmissionType struct {
+	Type            string `json:"type"`
+	ExternalToolUrl string `json:"external_tool_url,omitempty"`
+}
+
+type LtiLineItemQuery struct {
+	ResourceLinkId string
+	ResourceId     string
+	Tag            string
+	Limit          int
+}
+
+type LtiLineItemPage struct {
+	LineItems []LtiLineItem
+	NextUrl   string
+}
+
+type LtiScoreSubmission struct {
+	StartedAt   string `json:"startedAt,omitempty"`
+	SubmittedAt string `json:"submittedAt,omitempty"`
+}
+
+type LtiScore struct {
+	UserId           string               `json:"userId"`
+	ScoreGiven       *float64             `json:"scoreGiven,omitempty"`
+	ScoreMaximum     *float64             `json:"scoreMaximum,omitempty"`
+	Comment          string               `json:"comment,omitempty"`
+	Timestamp        string               `json:"timestamp"`
+	ActivityProgress string               `json:"activityProgress"`
+	GradingProgress  string               `json:"gradingProgress"`
+	Submission       *LtiScoreSubmission  `json:"submission,omitempty"`
+	CanvasSubmission *CanvasLtiSubmission `json:"https://canvas.instructure.com/lti/submission,omitempty"`
+}
+
+// CanvasLtiSubmission is the Canvas extension for attaching submission content to a score
+type CanvasLtiSubmission struct {
+	NewSubmission   *bool                            `json:"new_submission,omitempty"`
+	PreferCreatedAt bool                             `json:"prefer_created_at,omitempty"`
+	SubmissionType  string                           `json:"submission_type,omitempty"`
+	SubmissionData  string                           `json:"submission_data,omitempty"`
+	SubmittedAt     string                           `json:"submitted_at,omitempty"`
+	ContentItems    []CanvasLtiSubmissionContentItem `json:"content_items,omitempty"`
+}
+
+type CanvasLtiSubmissionContentItem struct {
+	Type      string `json:"type"`
+	Url       string `json:"url"`
+	Title     string `json:"title,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+}
+
+type LtiResult struct {
+	Id            string   `json:"id"`
+	ScoreOf       string   `json:"scoreOf"`
+	UserId        string   `json:"userId"`
+	ResultScore   *float64 `json:"resultScore,omitempty"`
+	ResultMaximum *float64 `json:"resultMaximum,omitempty"`
+	Comment       string   `json:"comment,omitempty"`
+}
+
+type LtiResultQuery struct {
+	UserId string
+	Limit  int
+}
+
+type LtiResultPage struct {
+	Results []LtiResult
+	NextUrl string
+}
+
+// LtiScoreRequest is a score the tool frontend posts for a line item of the session's context
+type LtiScoreRequest struct {
+	LineItemUrl string   `json:"line_item_url"`
+	Score       LtiScore `json:"score"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type Oauth2RedirectRequest struct {
 	Code             string `query:"code"`
 	State            string `query:"state"`
@@ -14,6 +16,24 @@ type Oauth2ExchangeResponse struct {
 		Id   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"user"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 	ExpiresIn    int    `json:"expires_in"`
 	CanvasRegion string `json:"canvas_region"`
 }
+
+// CanvasUserRef identifies a Canvas user on an instance
+type CanvasUserRef struct {
+	Domain string `json:"domain"`
+	UserId int    `json:"user_id"`
+}
+
+// CanvasUserToken is the OAuth2 grant a Canvas user gave the tool
+type CanvasUserToken struct {
+	CanvasUserRef
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Revoked      bool      `json:"revoked"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,92 @@
+package dto
+
+type LtiDeepLinkingSettings struct {
+	DeepLinkReturnUrl                 string   `json:"deep_link_return_url"`
+	AcceptTypes                       []string `json:"accept_types"`
+	AcceptPresentationDocumentTargets []string `json:"accept_presentation_document_targets"`
+	AcceptMediaTypes                  string   `json:"accept_media_types"`
+	AcceptMultiple                    bool     `json:"accept_multiple"`
+	AcceptLineItem                    bool     `json:"accept_lineitem"`
+	AutoCreate                        bool     `json:"auto_create"`
+	Title                             string   `json:"title"`
+	Text                              string   `json:"text"`
+	Data                              string   `json:"data"`
+}
+
+type LtiDeepLinkingLaunch struct {
+	DeepLinkingId string                 `json:"deep_linking_id"`
+	Settings      LtiDeepLinkingSettings `json:"settings"`
+}
+
+type LtiDeepLinkingResponseRequest struct {
+	DeepLinkingId string           `json:"-"`
+	ContentItems  []LtiContentItem `json:"content_items"`
+	Msg           string           `json:"msg"`
+	Log           string           `json:"log"`
+	ErrorMsg      string           `json:"errormsg"`
+	ErrorLog      string           `json:"errorlog"`
+}
+
+type LtiDeepLinkingForm struct {
+	ReturnUrl string
+	Jwt       string
+}
+
+type LtiContentImage struct {
+	Url    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+type LtiContentWindow struct {
+	TargetName     string `json:"targetName,omitempty"`
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	WindowFeatures string `json:"windowFeatures,omitempty"`
+}
+
+type LtiContentIframe struct {
+	Src    string `json:"src,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+type LtiContentEmbed struct {
+	Html string `json:"html"`
+}
+
+type LtiContentLineItem struct {
+	Label          string  `json:"label,omitempty"`
+	ScoreMaximum   float64 `json:"scoreMaximum"`
+	ResourceId     string  `json:"resourceId,omitempty"`
+	Tag            string  `json:"tag,omitempty"`
+	GradesReleased *bool   `json:"gradesReleased,omitempty"`
+}
+
+type LtiContentTimeWindow struct {
+	StartDateTime string `json:"startDateTime,omitempty"`
+	EndDateTime   string `json:"endDateTime,omitempty"`
+}
+
+// LtiContentItem is a single Deep Linking 2.0 content item. Which fields apply
+// depends on Type (ltiResourceLink, link, file, html or image).
+type LtiContentItem struct {
+	Type       string                `json:"type"`
+	Title      string                `json:"title,omitempty"`
+	Text       string                `json:"text,omitempty"`
+	Url        string                `json:"url,omitempty"`
+	Html       string                `json:"html,omitempty"`
+	MediaType  string                `json:"mediaType,omitempty"`
+	ExpiresAt  string                `json:"expiresAt,omitempty"`
+	Width      int                   `json:"width,omitempty"`
+	Height     int                   `json:"height,omitempty"`
+	Icon       *LtiContentImage      `json:"icon,omitempty"`
+	Thumbnail  *LtiContentImage      `json:"thumbnail,omitempty"`
+	Embed      *LtiContentEmbed      `json:"embed,omitempty"`
+	Window     *LtiContentWindow     `json:"window,omitempty"`
+	Iframe     *LtiContentIframe     `json:"iframe,omitempty"`
+	LineItem   *LtiContentLineItem   `json:"lineItem,omitempty"`
+	Available  *LtiContentTimeWindow `json:"available,omitempty"`
+	Submission *LtiContentTimeWindow `json:"submission,omitempty"`
+	Custom     map[string]string     `json:"custom,omitempty"`
+}
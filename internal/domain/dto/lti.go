@@ -1,6 +1,11 @@
 package dto
 
 import (
+	"encoding/json"
+	"maps"
+	"strings"
+	"time"
+
 	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
@@ -24,12 +29,43 @@ type LtiLaunchRequest struct {
 	LtiStorageTarget  string `form:"lti_storage_target"`
 	Error             string `form:"error"`
 	ErrorDescription  string `form:"error_description"`
+	LtiStorageValue   string `form:"lti_storage_value"`
+}
+
+type LtiLoginResponse struct {
+	AuthUrl        string
+	PlatformOrigin string
+	StorageTarget  string
+	StorageKey     string
+	StorageValue   string
+	MessageId      string
+}
+
+type LtiStorageCheckPage struct {
+	LaunchUrl      string
+	PlatformOrigin string
+	StorageTarget  string
+	StorageKey     string
+	MessageId      string
+	IdToken        string
+	State          string
+}
+
+type LtiAccessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+	// ExpiresAt is when the token manager stops reusing the token, zero when it is not cached
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type JwksResponse struct {
 	Keys []jwk.Key `json:"keys"`
 }
 
+// LtiJwtTokenClaims are the claims of an LTI 1.3 launch id_token. Claims that
+// are not modelled are available through RawClaims and Claim.
 type LtiJwtTokenClaims struct {
 	MessageType  string `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
 	Version      string `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
@@ -41,14 +77,22 @@ type LtiJwtTokenClaims struct {
 	Aud           []string `json:"aud"`
 	Azp           string   `json:"azp"`
 	DeploymentID  string   `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
-	Exp           string   `json:"exp"`
-	Iat           string   `json:"iat"`
+	Exp           int64    `json:"exp"`
+	Iat           int64    `json:"iat"`
 	Iss           string   `json:"iss"`
 	Nonce         string   `json:"nonce"`
 	Sub           string   `json:"sub"`
 	TargetLinkURI string   `json:"https://purl.imsglobal.org/spec/lti/claim/target_link_uri"`
-	Context       struct {
+	// User identity claims, sent depending on the privacy settings of the platform
+	Name       string `json:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+	MiddleName string `json:"middle_name,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Picture    string `json:"picture,omitempty"`
+	Context    struct {
 		ID    string   `json:"id"`
+		Label string   `json:"label"`
 		Title string   `json:"title"`
 		Type  []string `json:"type"`
 	} `json:"https://purl.imsglobal.org/spec/lti/claim/context"`
@@ -57,6 +101,9 @@ type LtiJwtTokenClaims struct {
 		Name              string `json:"name"`
 		Version           string `json:"version"`
 		ProductFamilyCode string `json:"product_family_code"`
+		Url               string `json:"url,omitempty"`
+		ContactEmail      string `json:"contact_email,omitempty"`
+		Description       string `json:"description,omitempty"`
 	} `json:"https://purl.imsglobal.org/spec/lti/claim/tool_platform"`
 	LaunchPresentation struct {
 		DocumentTarget string `json:"document_target"`
@@ -71,12 +118,20 @@ type LtiJwtTokenClaims struct {
 		Scope                   []string `json:"scope"`
 		NoticeTypesSupported    []string `json:"notice_types_supported"`
 	} `json:"https://purl.imsglobal.org/spec/lti/claim/platformnotificationservice"`
-	Locale   string   `json:"locale"`
-	Roles    []string `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
-	Custom   struct{} `json:"https://purl.imsglobal.org/spec/lti/claim/custom"`
+	Locale string   `json:"locale"`
+	Roles  []string `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
+	// RoleScopeMentor lists the user ids a mentor launch is about
+	RoleScopeMentor []string `json:"https://purl.imsglobal.org/spec/lti/claim/role_scope_mentor,omitempty"`
+	Lis             struct {
+		PersonSourcedId         string `json:"person_sourcedid,omitempty"`
+		CourseOfferingSourcedId string `json:"course_offering_sourcedid,omitempty"`
+		CourseSectionSourcedId  string `json:"course_section_sourcedid,omitempty"`
+	} `json:"https://purl.imsglobal.org/spec/lti/claim/lis"`
+	Custom   LtiCustomClaims `json:"https://purl.imsglobal.org/spec/lti/claim/custom,omitempty"`
 	Endpoint struct {
 		Scope     []string `json:"scope"`
 		LineItems string   `json:"lineitems"`
+		LineItem  string   `json:"lineitem,omitempty"`
 	} `json:"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint"`
 	NamesRoleService struct {
 		ContextMembershipsUrl string   `json:"context_memberships_url"`
@@ -86,5 +141,95 @@ type LtiJwtTokenClaims struct {
 	Lti1p1            struct {
 		UserID string `json:"user_id"`
 	} `json:"https://purl.imsglobal.org/spec/lti/claim/lti1p1"`
-	Placement string `json:"https://www.instructure.com/placement"`
+	// Canvas extension claims
+	Placement           string                 `json:"https://www.instructure.com/placement"`
+	LtiStudentId        string                 `json:"https://www.instructure.com/lti_student_id,omitempty"`
+	DeepLinkingSettings LtiDeepLinkingSettings `json:"https://purl.imsglobal.org/spec/lti-dl/claim/deep_linking_settings"`
+
+	// raw holds every claim of the token, including the ones not modelled above
+	raw map[string]any
+}
+
+// LtiCustomClaims are the custom parameters of a launch. Substitution variables
+// expanded to numbers, booleans or objects are kept as their JSON text, the
+// original values are available through RawClaims.
+type LtiCustomClaims map[string]string
+
+// UnmarshalJSON decodes custom parameters without rejecting values that are not strings
+func (c *LtiCustomClaims) UnmarshalJSON(data []byte) error {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	custom := make(LtiCustomClaims, len(values))
+	for key, value := range values {
+		var s string
+		switch err := json.Unmarshal(value, &s); {
+		case err == nil:
+			custom[key] = s
+		case string(value) == "null":
+			custom[key] = ""
+		default:
+			custom[key] = string(value)
+		}
+	}
+	*c = custom
+
+	return nil
+}
+
+// CanvasClaimPrefix is the prefix of the Canvas extension claims
+const CanvasClaimPrefix = "https://www.instructure.com/"
+
+// ltiJwtTokenClaims has the fields of LtiJwtTokenClaims without its JSON methods
+type ltiJwtTokenClaims LtiJwtTokenClaims
+
+// UnmarshalJSON decodes the modelled claims and keeps every claim for RawClaims
+func (c *LtiJwtTokenClaims) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*ltiJwtTokenClaims)(c)); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &c.raw)
+}
+
+// MarshalJSON encodes the modelled claims over the raw claims, so unmodelled claims survive a round trip
+func (c LtiJwtTokenClaims) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(ltiJwtTokenClaims(c))
+	if err != nil || c.raw == nil {
+		return data, err
+	}
+
+	var modelled map[string]any
+	if err := json.Unmarshal(data, &modelled); err != nil {
+		return nil, err
+	}
+	claims := maps.Clone(c.raw)
+	maps.Copy(claims, modelled)
+
+	return json.Marshal(claims)
+}
+
+// RawClaims returns every claim of the token as decoded from JSON
+func (c *LtiJwtTokenClaims) RawClaims() map[string]any {
+	return c.raw
+}
+
+// Claim returns a claim of the token by name, including claims that are not modelled
+func (c *LtiJwtTokenClaims) Claim(name string) (any, bool) {
+	value, ok := c.raw[name]
+	return value, ok
+}
+
+// CanvasClaims returns the Canvas extension claims of the token, keyed by their full name
+func (c *LtiJwtTokenClaims) CanvasClaims() map[string]any {
+	claims := make(map[string]any)
+	for name, value := range c.raw {
+		if strings.HasPrefix(name, CanvasClaimPrefix) {
+			claims[name] = value
+		}
+	}
+
+	return claims
 }
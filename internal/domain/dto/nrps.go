@@ -0,0 +1,46 @@
+package dto
+
+type LtiMembershipQuery struct {
+	Role           string
+	Limit          int
+	ResourceLinkId string
+}
+
+type LtiMembershipContext struct {
+	Id    string `json:"id"`
+	Label string `json:"label"`
+	Title string `json:"title"`
+}
+
+type LtiMember struct {
+	Status             string           `json:"status"`
+	Name               string           `json:"name"`
+	Picture            string           `json:"picture"`
+	GivenName          string           `json:"given_name"`
+	FamilyName         string           `json:"family_name"`
+	MiddleName         string           `json:"middle_name"`
+	Email              string           `json:"email"`
+	UserId             string           `json:"user_id"`
+	LisPersonSourcedId string           `json:"lis_person_sourcedid"`
+	Lti11LegacyUserId  string           `json:"lti11_legacy_user_id"`
+	Roles              []string         `json:"roles"`
+	Message            []map[string]any `json:"message,omitempty"`
+}
+
+type LtiMembershipContainer struct {
+	Id      string               `json:"id"`
+	Context LtiMembershipContext `json:"context"`
+	Members []LtiMember          `json:"members"`
+}
+
+type LtiMembershipPage struct {
+	LtiMembershipContainer
+	NextUrl string
+	// DifferencesUrl returns only the changes since this page was fetched
+	DifferencesUrl string
+}
+
+// LtiMembersRequest filters the roster of the session's context
+type LtiMembersRequest struct {
+	Role string `query:"role"`
+}
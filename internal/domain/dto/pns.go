@@ -0,0 +1,50 @@
+package dto
+
+type LtiNoticeHandler struct {
+	NoticeType   string `json:"notice_type"`
+	Handler      string `json:"handler"`
+	MaxBatchSize int    `json:"max_batch_size,omitempty"`
+}
+
+type LtiNoticeHandlersResponse struct {
+	ClientId       string             `json:"client_id"`
+	DeploymentId   string             `json:"deployment_id"`
+	NoticeHandlers []LtiNoticeHandler `json:"notice_handlers"`
+}
+
+type LtiNoticeRequest struct {
+	Notices []struct {
+		Jwt string `json:"jwt"`
+	} `json:"notices"`
+}
+
+type LtiNoticeClaim struct {
+	Id        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+}
+
+// LtiNotice is a verified platform notice. Claims holds every claim of the
+// notice JWT so callbacks can decode the type specific ones.
+type LtiNotice struct {
+	Jti          string           `json:"jti"`
+	Iss          string           `json:"iss"`
+	DeploymentId string           `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	Version      string           `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	Notice       LtiNoticeClaim   `json:"https://purl.imsglobal.org/spec/lti/claim/notice"`
+	Claims       map[string]any   `json:"-"`
+	Registration *LtiRegistration `json:"-"`
+}
+
+// LtiNoticeResult is the outcome of one notice of a batch
+type LtiNoticeResult struct {
+	// Id is the notice id, empty when the notice could not be verified
+	Id     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// LtiNoticeResponse reports every notice of a batch in the order they were sent
+type LtiNoticeResponse struct {
+	Notices []LtiNoticeResult `json:"notices"`
+}
@@ -0,0 +1,73 @@
+package dto
+
+// LtiRegistration describes a tool registration on a single LTI platform.
+// A registration is identified by its issuer and client_id and may be
+// installed through one or more deployments.
+type LtiRegistration struct {
+	Issuer        string   `json:"issuer"`
+	ClientId      string   `json:"client_id"`
+	DeploymentIds []string `json:"deployment_ids"`
+	AuthLoginUrl  string   `json:"auth_login_url"`
+	AuthTokenUrl  string   `json:"auth_token_url"`
+	JwksUrl       string   `json:"jwks_url"`
+	// KeyId pins the kid of the tool key used to sign messages for this registration,
+	// the current key of the key ring when empty
+	KeyId string `json:"key_id,omitempty"`
+}
+
+type LtiDynamicRegistrationRequest struct {
+	OpenidConfiguration string `query:"openid_configuration"`
+	RegistrationToken   string `query:"registration_token"`
+}
+
+type LtiPlatformMessage struct {
+	Type       string   `json:"type"`
+	Placements []string `json:"placements,omitempty"`
+}
+
+type OpenidConfiguration struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JwksUri               string   `json:"jwks_uri"`
+	RegistrationEndpoint  string   `json:"registration_endpoint"`
+	ScopesSupported       []string `json:"scopes_supported"`
+	ClaimsSupported       []string `json:"claims_supported"`
+	PlatformConfiguration struct {
+		ProductFamilyCode string               `json:"product_family_code"`
+		Version           string               `json:"version"`
+		MessagesSupported []LtiPlatformMessage `json:"messages_supported"`
+		Variables         []string             `json:"variables"`
+	} `json:"https://purl.imsglobal.org/spec/lti-platform-configuration"`
+}
+
+type LtiToolMessage struct {
+	Type          string            `json:"type"`
+	TargetLinkUri string            `json:"target_link_uri,omitempty"`
+	Label         string            `json:"label,omitempty"`
+	Placements    []string          `json:"placements,omitempty"`
+	CustomParams  map[string]string `json:"custom_parameters,omitempty"`
+}
+
+type LtiToolConfiguration struct {
+	Domain        string           `json:"domain"`
+	TargetLinkUri string           `json:"target_link_uri"`
+	Claims        []string         `json:"claims"`
+	Messages      []LtiToolMessage `json:"messages"`
+	DeploymentId  string           `json:"deployment_id,omitempty"`
+	PrivacyLevel  string           `json:"https://canvas.instructure.com/lti/privacy_level,omitempty"`
+}
+
+type LtiToolRegistration struct {
+	ClientId                string               `json:"client_id,omitempty"`
+	ApplicationType         string               `json:"application_type"`
+	ResponseTypes           []string             `json:"response_types"`
+	GrantTypes              []string             `json:"grant_types"`
+	InitiateLoginUri        string               `json:"initiate_login_uri"`
+	RedirectUris            []string             `json:"redirect_uris"`
+	ClientName              string               `json:"client_name"`
+	JwksUri                 string               `json:"jwks_uri"`
+	TokenEndpointAuthMethod string               `json:"token_endpoint_auth_method"`
+	Scope                   string               `json:"scope"`
+	ToolConfiguration       LtiToolConfiguration `json:"https://purl.imsglobal.org/spec/lti-tool-configuration"`
+}
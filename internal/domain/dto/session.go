@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// ToolSession is the state of an authenticated launch, used by requests the
+// tool frontend makes after the launch.
+type ToolSession struct {
+	Id                  string                  `json:"-"`
+	Issuer              string                  `json:"issuer"`
+	ClientId            string                  `json:"client_id"`
+	DeploymentId        string                  `json:"deployment_id"`
+	UserId              string                  `json:"user_id"`
+	Name                string                  `json:"name,omitempty"`
+	Email               string                  `json:"email,omitempty"`
+	Locale              string                  `json:"locale"`
+	Roles               []string                `json:"roles"`
+	ContextId           string                  `json:"context_id"`
+	ContextTitle        string                  `json:"context_title"`
+	ResourceLinkId      string                  `json:"resource_link_id"`
+	MessageType         string                  `json:"message_type"`
+	LineItemsUrl        string                  `json:"line_items_url,omitempty"`
+	AgsScopes           []string                `json:"ags_scopes,omitempty"`
+	MembershipsUrl      string                  `json:"memberships_url,omitempty"`
+	NoticeHandlerUrl    string                  `json:"notice_handler_url,omitempty"`
+	DeepLinkingId       string                  `json:"deep_linking_id,omitempty"`
+	DeepLinkingSettings *LtiDeepLinkingSettings `json:"deep_linking_settings,omitempty"`
+	ExpiresAt           time.Time               `json:"expires_at"`
+}
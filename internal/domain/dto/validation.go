@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClaimError is a claim of a platform JWT that failed validation
+type ClaimError struct {
+	Claim   string `json:"claim"`
+	Message string `json:"message"`
+}
+
+// ClaimsValidationError lists every claim of a platform JWT that failed validation
+type ClaimsValidationError struct {
+	// Token names the validated token, such as id_token
+	Token  string
+	Errors []ClaimError
+}
+
+func (e *ClaimsValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, claimError := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", claimError.Claim, claimError.Message))
+	}
+
+	return fmt.Sprintf("invalid %s: %s", e.Token, strings.Join(messages, "; "))
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"go-lti/internal/domain/dto"
+)
+
+type AgsClient interface {
+	ListLineItems(ctx context.Context, registration *dto.LtiRegistration, lineItemsUrl string, query *dto.LtiLineItemQuery) (*dto.LtiLineItemPage, error)
+	GetLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string) (*dto.LtiLineItem, error)
+	CreateLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItemsUrl string, lineItem *dto.LtiLineItem) (*dto.LtiLineItem, error)
+	UpdateLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItem *dto.LtiLineItem) (*dto.LtiLineItem, error)
+	DeleteLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string) error
+	PostScore(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string, score *dto.LtiScore) error
+	ListResults(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string, query *dto.LtiResultQuery) (*dto.LtiResultPage, error)
+}
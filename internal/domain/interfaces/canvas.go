@@ -1,7 +1,9 @@
 package interfaces
 
 import (
+	"context"
 	"go-lti/internal/domain/dto"
+	"go-lti/lib/canvasapi"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -9,6 +11,17 @@ import (
 type CanvasService interface {
 	Oauth2Login(c *fiber.Ctx) (string, error)
 	Oauth2Redirect(c *fiber.Ctx, request *dto.Oauth2RedirectRequest) (*dto.Oauth2ExchangeResponse, error)
-	Oauth2Refresh(c *fiber.Ctx, refreshToken string) (string, error)
-	GetUserInfo(c *fiber.Ctx, accessToken string) (any, error)
+	Oauth2Refresh(ctx context.Context, refreshToken string) (*dto.Oauth2ExchangeResponse, error)
+	Oauth2Logout(c *fiber.Ctx) error
+	CurrentUser(c *fiber.Ctx) (*dto.CanvasUserRef, error)
+	AccessToken(ctx context.Context, user dto.CanvasUserRef) (string, error)
+	DeleteToken(ctx context.Context, user dto.CanvasUserRef) error
+	Api(user dto.CanvasUserRef) *canvasapi.Client
+	GetUserInfo(c *fiber.Ctx, accessToken string) (*canvasapi.User, error)
+}
+
+type CanvasTokenStore interface {
+	Get(ctx context.Context, user dto.CanvasUserRef) (*dto.CanvasUserToken, error)
+	Save(ctx context.Context, token *dto.CanvasUserToken) error
+	Delete(ctx context.Context, user dto.CanvasUserRef) error
 }
@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"go-lti/internal/domain/dto"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,7 +9,14 @@ import (
 
 type LtiService interface {
 	GetJwks(c *fiber.Ctx) (*dto.JwksResponse, error)
-	LtiLogin(c *fiber.Ctx, request *dto.LtiLoginRequest) (string, error)
+	LtiLogin(c *fiber.Ctx, request *dto.LtiLoginRequest) (*dto.LtiLoginResponse, error)
 	LtiLaunch(c *fiber.Ctx, request *dto.LtiLaunchRequest) (*dto.LtiJwtTokenClaims, error)
-	RequestAccessToken(c *fiber.Ctx) (any, error)
+	StartDeepLinking(c *fiber.Ctx, claims *dto.LtiJwtTokenClaims) (*dto.LtiDeepLinkingLaunch, error)
+	DeepLinkingResponse(c *fiber.Ctx, request *dto.LtiDeepLinkingResponseRequest) (*dto.LtiDeepLinkingForm, error)
+	DynamicRegistration(c *fiber.Ctx, request *dto.LtiDynamicRegistrationRequest) (*dto.LtiRegistration, error)
+}
+
+type LtiTokenManager interface {
+	Token(ctx context.Context, registration *dto.LtiRegistration, scopes []string) (*dto.LtiAccessTokenResponse, error)
+	Invalidate(registration *dto.LtiRegistration, scopes []string, accessToken string)
 }
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"go-lti/internal/domain/dto"
+)
+
+type NrpsClient interface {
+	GetMemberships(ctx context.Context, registration *dto.LtiRegistration, membershipsUrl string, query *dto.LtiMembershipQuery) (*dto.LtiMembershipPage, error)
+	GetAllMemberships(ctx context.Context, registration *dto.LtiRegistration, membershipsUrl string, query *dto.LtiMembershipQuery) (*dto.LtiMembershipPage, error)
+}
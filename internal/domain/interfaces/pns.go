@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"go-lti/internal/domain/dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NoticeCallback processes a verified notice. Platforms may redeliver a
+// notice, so callbacks should be idempotent on the notice id.
+type NoticeCallback func(ctx context.Context, notice *dto.LtiNotice) error
+
+type NoticeService interface {
+	On(noticeType string, callback NoticeCallback)
+	RegisterHandler(ctx context.Context, registration *dto.LtiRegistration, serviceUrl string, handler *dto.LtiNoticeHandler) (*dto.LtiNoticeHandler, error)
+	RegisterHandlers(ctx context.Context, registration *dto.LtiRegistration, serviceUrl string) error
+	SubscribeLaunch(claims *dto.LtiJwtTokenClaims)
+	ListHandlers(ctx context.Context, registration *dto.LtiRegistration, serviceUrl string) (*dto.LtiNoticeHandlersResponse, error)
+	ReceiveNotices(c *fiber.Ctx, request *dto.LtiNoticeRequest) (*dto.LtiNoticeResponse, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "go-lti/internal/domain/dto"
+
+type RegistrationStore interface {
+	Find(issuer string, clientId string) (*dto.LtiRegistration, error)
+	FindDeployment(issuer string, clientId string, deploymentId string) (*dto.LtiRegistration, error)
+	List() []dto.LtiRegistration
+	Save(registration *dto.LtiRegistration) error
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"go-lti/internal/domain/dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionService interface {
+	Create(c *fiber.Ctx, claims *dto.LtiJwtTokenClaims, deepLinkingId string) (*dto.ToolSession, string, error)
+	Get(c *fiber.Ctx, token string) (*dto.ToolSession, error)
+	Delete(c *fiber.Ctx, token string) error
+	RedirectUrl(claims *dto.LtiJwtTokenClaims, token string, deepLinkingId string) (string, error)
+}
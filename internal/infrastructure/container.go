@@ -1,11 +1,19 @@
 package infrastructure
 
 import (
+	"context"
 	"go-lti/internal/canvas"
+	"go-lti/internal/domain/dto"
 	"go-lti/internal/domain/interfaces"
 	"go-lti/internal/lti"
+	"go-lti/internal/registration"
+	"go-lti/internal/session"
+	"go-lti/lib/canvasapi"
 	"go-lti/lib/config"
 	"go-lti/lib/httpclient"
+	"go-lti/lib/jwks"
+	"go-lti/lib/keys"
+	"go-lti/lib/store"
 	"log"
 	"time"
 )
@@ -13,10 +21,23 @@ import (
 var (
 	cfg config.AppConfig
 
-	httpClient httpclient.HttpClient
+	keyManager     *keys.Manager
+	httpClient     httpclient.HttpClient
+	jwksCache      *jwks.Cache
+	canvasThrottle *canvasapi.Throttle
+	keyValueStore  store.Store
 
-	ltiService    interfaces.LtiService
-	canvasService interfaces.CanvasService
+	registrationStore interfaces.RegistrationStore
+	canvasTokenStore  interfaces.CanvasTokenStore
+	ltiTokenManager   interfaces.LtiTokenManager
+
+	ltiService     interfaces.LtiService
+	canvasService  interfaces.CanvasService
+	sessionService interfaces.SessionService
+
+	agsClient     interfaces.AgsClient
+	nrpsClient    interfaces.NrpsClient
+	noticeService interfaces.NoticeService
 )
 
 func init() {
@@ -26,14 +47,67 @@ func init() {
 		log.Fatalf("Failed to setup config: %v", err)
 	}
 
+	keyManager, err = keys.NewManager(keys.Config{
+		PrivateKeyPath: cfg.KeyConfig.PrivateKeyPath,
+		PublicKeyPath:  cfg.KeyConfig.PublicKeyPath,
+		KeyId:          cfg.LtiConfig.JwkKid,
+		Algorithm:      cfg.LtiConfig.JwkAlg,
+		RingPath:       cfg.KeyConfig.RingPath,
+		RotationDelay:  cfg.KeyConfig.RotationDelay,
+		RetireAfter:    cfg.KeyConfig.RetireAfter,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load tool keys: %v", err)
+	}
+
 	httpClient = httpclient.NewHttpClient(&httpclient.Config{
-		Timeout:          10 * time.Second,
-		MaxRetries:       3,
-		RetryWaitTime:    1 * time.Second,
-		MaxRetryWaitTime: 10 * time.Second,
-		DebugMode:        false,
+		Timeout:           10 * time.Second,
+		MaxRetries:        3,
+		RetryWaitTime:     1 * time.Second,
+		MaxRetryWaitTime:  10 * time.Second,
+		DebugMode:         false,
+		RetryStatusCodes:  httpclient.DefaultRetryStatusCodes,
+		IdempotentMethods: httpclient.DefaultIdempotentMethods,
+		Jitter:            0.2,
+		Middlewares: []httpclient.Middleware{
+			httpclient.Tracing(nil, nil),
+			httpclient.Metrics(nil),
+		},
 	})
 
-	ltiService = lti.NewService(cfg, httpClient)
-	canvasService = canvas.NewService(cfg, httpClient)
+	jwksCache = jwks.NewCache(httpClient, jwks.DefaultConfig())
+	canvasThrottle = canvasapi.NewThrottle(canvasapi.DefaultThrottleConfig())
+
+	keyValueStore, err = store.New(store.Config{
+		Driver:   cfg.StoreConfig.Driver,
+		Path:     cfg.StoreConfig.Path,
+		RedisUrl: cfg.StoreConfig.RedisUrl,
+	})
+	if err != nil {
+		log.Fatalf("Failed to setup store: %v", err)
+	}
+
+	registrationStore, err = registration.NewStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to setup registration store: %v", err)
+	}
+
+	canvasTokenStore, err = canvas.NewTokenStore(cfg, keyValueStore)
+	if err != nil {
+		log.Fatalf("Failed to setup canvas token store: %v", err)
+	}
+
+	ltiTokenManager = lti.NewTokenManager(cfg, keyManager, httpClient)
+
+	ltiService = lti.NewService(cfg, keyManager, httpClient, registrationStore, jwksCache, keyValueStore)
+	canvasService = canvas.NewService(cfg, httpClient, keyValueStore, canvasTokenStore, canvasThrottle)
+	sessionService = session.NewService(cfg, keyValueStore)
+
+	agsClient = lti.NewAgsClient(httpClient, ltiTokenManager)
+	nrpsClient = lti.NewNrpsClient(httpClient, ltiTokenManager)
+	noticeService = lti.NewNoticeService(cfg, httpClient, ltiTokenManager, registrationStore, jwksCache, keyValueStore)
+	noticeService.On(lti.NoticeTypeHelloWorld, func(ctx context.Context, notice *dto.LtiNotice) error {
+		log.Printf("Received hello world notice %s from %s", notice.Notice.Id, notice.Iss)
+		return nil
+	})
 }
@@ -1,9 +1,11 @@
 package infrastructure
 
 import (
+	"go-lti/internal/admin"
 	infra_app "go-lti/internal/app"
 	"go-lti/internal/canvas"
 	"go-lti/internal/lti"
+	"go-lti/internal/session"
 	"go-lti/lib/common"
 	"os"
 	"os/signal"
@@ -28,8 +30,12 @@ func Run() {
 	api := app.Group("/api")
 	v1 := api.Group("/v1")
 	infra_app.NewHttpHandler(v1)
-	lti.NewHttpHandler(v1.Group("/lti"), ltiService)
+	lti.NewHttpHandler(v1.Group("/lti"), ltiService, noticeService, sessionService)
+	lti.NewAgsHttpHandler(v1.Group("/lti/ags"), agsClient, registrationStore, sessionService)
+	lti.NewNrpsHttpHandler(v1.Group("/lti/nrps"), nrpsClient, registrationStore, sessionService)
+	session.NewHttpHandler(v1.Group("/session"), sessionService)
 	canvas.NewHttpHandler(v1.Group("/canvas"), canvasService)
+	admin.NewHttpHandler(v1.Group("/admin"), cfg.AdminConfig.Token, keyManager)
 
 	go func() {
 		if err := app.Listen(":3000"); err != nil {
@@ -45,9 +51,9 @@ func Run() {
 	app.Shutdown()
 	log.Info().Msg("Running cleanup tasks")
 
-	// Your cleanup tasks here
-	// db.Close()
-	// redisConn.Close()
+	if err := keyValueStore.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close store")
+	}
 
 	log.Info().Msg("Server shutdown complete")
 }
@@ -0,0 +1,77 @@
+package lti
+
+import (
+	"context"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/config"
+	"go-lti/lib/httpclient"
+	"go-lti/lib/keys"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	ScopeLineItem                  = "https://purl.imsglobal.org/spec/lti-ags/scope/lineitem"
+	ScopeLineItemReadonly          = "https://purl.imsglobal.org/spec/lti-ags/scope/lineitem.readonly"
+	ScopeResultReadonly            = "https://purl.imsglobal.org/spec/lti-ags/scope/result.readonly"
+	ScopeScore                     = "https://purl.imsglobal.org/spec/lti-ags/scope/score"
+	ScopeContextMembershipReadonly = "https://purl.imsglobal.org/spec/lti-nrps/scope/contextmembership.readonly"
+	ScopeNoticeHandlers            = "https://purl.imsglobal.org/spec/lti/scope/noticehandlers"
+)
+
+// requestAccessToken : Request a client credentials access token for the given scopes from the platform of a registration
+func requestAccessToken(ctx context.Context, cfg config.AppConfig, keys *keys.Manager, httpClient httpclient.HttpClient, registration *dto.LtiRegistration, scopes []string) (*dto.LtiAccessTokenResponse, error) {
+	clientAssertion, err := generateJWT(cfg, keys, registration)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]string{
+		"grant_type":            "client_credentials",
+		"client_assertion_type": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer",
+		"client_assertion":      clientAssertion,
+		"scope":                 strings.Join(scopes, " "),
+	}
+
+	var accessTokenResponse dto.LtiAccessTokenResponse
+	err = httpClient.Call(ctx, http.MethodPost, registration.AuthTokenUrl, map[string]string{
+		fiber.HeaderContentType: fiber.MIMEApplicationForm,
+		fiber.HeaderAccept:      fiber.MIMEApplicationJSON,
+	}, body, &accessTokenResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accessTokenResponse, nil
+}
+
+// callService : Make an LTI service request authorized with a cached access token for the given scope
+func callService(ctx context.Context, httpClient httpclient.HttpClient, tokens interfaces.LtiTokenManager, registration *dto.LtiRegistration, scope string, method string, requestUrl string, headers map[string]string, body interface{}, result interface{}) (*httpclient.Response, error) {
+	source := &scopedTokenSource{
+		tokens:       tokens,
+		registration: registration,
+		scopes:       []string{scope},
+	}
+
+	return httpClient.With(httpclient.BearerAuth(source)).Do(ctx, method, requestUrl, headers, body, result)
+}
+
+// generateJWT : Generate the client assertion JWT for an LTI access token request
+func generateJWT(cfg config.AppConfig, keys *keys.Manager, registration *dto.LtiRegistration) (string, error) {
+	// Create JWT
+	token := jwt.New()
+	token.Set(jwt.IssuerKey, cfg.LtiConfig.Issuer)
+	token.Set(jwt.SubjectKey, registration.ClientId)
+	token.Set(jwt.AudienceKey, registration.AuthTokenUrl)
+	token.Set(jwt.IssuedAtKey, time.Now().Unix())
+	token.Set(jwt.ExpirationKey, time.Now().Add(10*time.Minute).Unix())
+	token.Set(jwt.JwtIDKey, uuid.New().String())
+
+	return keys.Sign(registration.KeyId, token)
+}
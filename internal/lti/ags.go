@@ -0,0 +1,228 @@
+package lti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/httpclient"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MediaTypeLineItem          = "application/vnd.ims.lis.v2.lineitem+json"
+	MediaTypeLineItemContainer = "application/vnd.ims.lis.v2.lineitemcontainer+json"
+	MediaTypeScore             = "application/vnd.ims.lis.v1.score+json"
+	MediaTypeResultContainer   = "application/vnd.ims.lis.v2.resultcontainer+json"
+
+	ActivityProgressInitialized = "Initialized"
+	ActivityProgressStarted     = "Started"
+	ActivityProgressInProgress  = "InProgress"
+	ActivityProgressSubmitted   = "Submitted"
+	ActivityProgressCompleted   = "Completed"
+
+	GradingProgressFullyGraded   = "FullyGraded"
+	GradingProgressPending       = "Pending"
+	GradingProgressPendingManual = "PendingManual"
+	GradingProgressFailed        = "Failed"
+	GradingProgressNotReady      = "NotReady"
+)
+
+type agsClient struct {
+	httpClient httpclient.HttpClient
+	tokens     interfaces.LtiTokenManager
+}
+
+// ListLineItems : Return one page of line items; pass the page's NextUrl as lineItemsUrl to fetch the next one
+func (a *agsClient) ListLineItems(ctx context.Context, registration *dto.LtiRegistration, lineItemsUrl string, query *dto.LtiLineItemQuery) (*dto.LtiLineItemPage, error) {
+	params := url.Values{}
+	if query != nil {
+		setParam(params, "resource_link_id", query.ResourceLinkId)
+		setParam(params, "resource_id", query.ResourceId)
+		setParam(params, "tag", query.Tag)
+		if query.Limit > 0 {
+			params.Set("limit", strconv.Itoa(query.Limit))
+		}
+	}
+
+	requestUrl, err := withQuery(lineItemsUrl, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var lineItems []dto.LtiLineItem
+	res, err := callService(ctx, a.httpClient, a.tokens, registration, ScopeLineItemReadonly, http.MethodGet, requestUrl, map[string]string{
+		fiber.HeaderAccept: MediaTypeLineItemContainer,
+	}, nil, &lineItems)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LtiLineItemPage{
+		LineItems: lineItems,
+		NextUrl:   httpclient.NextLink(res.Header),
+	}, nil
+}
+
+// GetLineItem : Return a single line item
+func (a *agsClient) GetLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string) (*dto.LtiLineItem, error) {
+	var lineItem dto.LtiLineItem
+	_, err := callService(ctx, a.httpClient, a.tokens, registration, ScopeLineItemReadonly, http.MethodGet, lineItemUrl, map[string]string{
+		fiber.HeaderAccept: MediaTypeLineItem,
+	}, nil, &lineItem)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lineItem, nil
+}
+
+// CreateLineItem : Add a line item to the line items container of a context
+func (a *agsClient) CreateLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItemsUrl string, lineItem *dto.LtiLineItem) (*dto.LtiLineItem, error) {
+	if lineItem.Label == "" || lineItem.ScoreMaximum <= 0 {
+		return nil, errors.New("line item requires a label and a positive scoreMaximum")
+	}
+
+	var created dto.LtiLineItem
+	_, err := callService(ctx, a.httpClient, a.tokens, registration, ScopeLineItem, http.MethodPost, lineItemsUrl, map[string]string{
+		fiber.HeaderContentType: MediaTypeLineItem,
+		fiber.HeaderAccept:      MediaTypeLineItem,
+	}, lineItem, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// UpdateLineItem : Replace a line item, addressed by its id URL
+func (a *agsClient) UpdateLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItem *dto.LtiLineItem) (*dto.LtiLineItem, error) {
+	if lineItem.Id == "" {
+		return nil, errors.New("line item id is required")
+	}
+
+	var updated dto.LtiLineItem
+	_, err := callService(ctx, a.httpClient, a.tokens, registration, ScopeLineItem, http.MethodPut, lineItem.Id, map[string]string{
+		fiber.HeaderContentType: MediaTypeLineItem,
+		fiber.HeaderAccept:      MediaTypeLineItem,
+	}, lineItem, &updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteLineItem : Remove a line item and its results
+func (a *agsClient) DeleteLineItem(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string) error {
+	_, err := callService(ctx, a.httpClient, a.tokens, registration, ScopeLineItem, http.MethodDelete, lineItemUrl, nil, nil, nil)
+	return err
+}
+
+// PostScore : Publish a score for a user to the scores endpoint of a line item
+func (a *agsClient) PostScore(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string, score *dto.LtiScore) error {
+	if score.UserId == "" || score.Timestamp == "" {
+		return errors.New("score requires userId and timestamp")
+	}
+	if score.ActivityProgress == "" || score.GradingProgress == "" {
+		return errors.New("score requires activityProgress and gradingProgress")
+	}
+	if score.ScoreGiven != nil && score.ScoreMaximum == nil {
+		return errors.New("scoreMaximum is required when scoreGiven is set")
+	}
+
+	scoresUrl, err := subResource(lineItemUrl, "scores")
+	if err != nil {
+		return err
+	}
+
+	_, err = callService(ctx, a.httpClient, a.tokens, registration, ScopeScore, http.MethodPost, scoresUrl, map[string]string{
+		fiber.HeaderContentType: MediaTypeScore,
+	}, score, nil)
+	return err
+}
+
+// ListResults : Return one page of results of a line item; pass the page's NextUrl as lineItemUrl with a nil query to continue
+func (a *agsClient) ListResults(ctx context.Context, registration *dto.LtiRegistration, lineItemUrl string, query *dto.LtiResultQuery) (*dto.LtiResultPage, error) {
+	resultsUrl := lineItemUrl
+	if query != nil {
+		var err error
+		resultsUrl, err = subResource(lineItemUrl, "results")
+		if err != nil {
+			return nil, err
+		}
+
+		params := url.Values{}
+		setParam(params, "user_id", query.UserId)
+		if query.Limit > 0 {
+			params.Set("limit", strconv.Itoa(query.Limit))
+		}
+		if resultsUrl, err = withQuery(resultsUrl, params); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []dto.LtiResult
+	res, err := callService(ctx, a.httpClient, a.tokens, registration, ScopeResultReadonly, http.MethodGet, resultsUrl, map[string]string{
+		fiber.HeaderAccept: MediaTypeResultContainer,
+	}, nil, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LtiResultPage{
+		Results: results,
+		NextUrl: httpclient.NextLink(res.Header),
+	}, nil
+}
+
+// subResource : Append a path segment to a line item URL while keeping its query string
+func subResource(lineItemUrl string, segment string) (string, error) {
+	u, err := url.Parse(lineItemUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid line item url: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + segment
+
+	return u.String(), nil
+}
+
+// withQuery : Merge query parameters into a URL that may already have some
+func withQuery(rawUrl string, params url.Values) (string, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func setParam(params url.Values, key string, value string) {
+	if value != "" {
+		params.Set(key, value)
+	}
+}
+
+func NewAgsClient(
+	httpClient httpclient.HttpClient,
+	tokens interfaces.LtiTokenManager,
+) interfaces.AgsClient {
+	return &agsClient{
+		httpClient: httpClient,
+		tokens:     tokens,
+	}
+}
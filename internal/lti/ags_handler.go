@@ -0,0 +1,123 @@
+package lti
+
+import (
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/internal/roles"
+	"go-lti/internal/session"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type agsHttpHandler struct {
+	agsClient     interfaces.AgsClient
+	registrations interfaces.RegistrationStore
+}
+
+// NewAgsHttpHandler serves the line items and scores of the launch context to its instructors
+func NewAgsHttpHandler(r fiber.Router, agsClient interfaces.AgsClient, registrations interfaces.RegistrationStore, sessionService interfaces.SessionService) {
+	handler := &agsHttpHandler{
+		agsClient:     agsClient,
+		registrations: registrations,
+	}
+
+	r.Use(session.Middleware(sessionService), roles.Require(roles.Instructor, roles.TeachingAssistant))
+	r.Get("/lineitems", handler.listLineItems)
+	r.Post("/scores", handler.postScore)
+}
+
+func (h *agsHttpHandler) listLineItems(c *fiber.Ctx) error {
+	toolSession := session.FromContext(c)
+	if toolSession.LineItemsUrl == "" {
+		return fiber.NewError(fiber.StatusNotFound, "launch has no line items service")
+	}
+
+	registration, err := sessionRegistration(h.registrations, toolSession)
+	if err != nil {
+		return err
+	}
+
+	// pages are followed here, the platform URLs are never handed to the frontend
+	lineItems := []dto.LtiLineItem{}
+	nextUrl := toolSession.LineItemsUrl
+	for nextUrl != "" {
+		page, err := h.agsClient.ListLineItems(c.Context(), registration, nextUrl, nil)
+		if err != nil {
+			return err
+		}
+		lineItems = append(lineItems, page.LineItems...)
+		nextUrl = page.NextUrl
+	}
+
+	return c.JSON(lineItems)
+}
+
+func (h *agsHttpHandler) postScore(c *fiber.Ctx) error {
+	request := new(dto.LtiScoreRequest)
+	if err := c.BodyParser(request); err != nil {
+		return err
+	}
+
+	toolSession := session.FromContext(c)
+	if !slices.Contains(toolSession.AgsScopes, ScopeScore) {
+		return fiber.NewError(fiber.StatusForbidden, "launch does not allow posting scores")
+	}
+	// the service token can post to any line item of the platform, only the session's context is allowed
+	if !withinUrl(toolSession.LineItemsUrl, request.LineItemUrl) {
+		return fiber.NewError(fiber.StatusBadRequest, "line_item_url is not a line item of the launch context")
+	}
+
+	score := &request.Score
+	if score.UserId == "" || score.ActivityProgress == "" || score.GradingProgress == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "score requires userId, activityProgress and gradingProgress")
+	}
+	if score.ScoreGiven != nil && score.ScoreMaximum == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "scoreMaximum is required when scoreGiven is set")
+	}
+	if score.Timestamp == "" {
+		score.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+
+	registration, err := sessionRegistration(h.registrations, toolSession)
+	if err != nil {
+		return err
+	}
+
+	if err := h.agsClient.PostScore(c.Context(), registration, request.LineItemUrl, score); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// sessionRegistration : Return the registration the session was launched from
+func sessionRegistration(registrations interfaces.RegistrationStore, toolSession *dto.ToolSession) (*dto.LtiRegistration, error) {
+	registration, err := registrations.FindDeployment(toolSession.Issuer, toolSession.ClientId, toolSession.DeploymentId)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "the registration of the launch no longer exists")
+	}
+
+	return registration, nil
+}
+
+// withinUrl : Report whether rawUrl is below the service URL base, on the same host and path
+func withinUrl(base string, rawUrl string) bool {
+	baseUrl, err := url.Parse(base)
+	if err != nil || base == "" {
+		return false
+	}
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	if u.Scheme != baseUrl.Scheme || u.Host != baseUrl.Host || path.Clean(u.Path) != u.Path {
+		return false
+	}
+
+	return strings.HasPrefix(u.Path, strings.TrimSuffix(baseUrl.Path, "/")+"/")
+}
@@ -0,0 +1,34 @@
+package lti
+
+import "testing"
+
+func TestWithinUrl(t *testing.T) {
+	base := "https://canvas.test/api/lti/courses/1/line_items"
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://canvas.test/api/lti/courses/1/line_items/5", true},
+		{"https://canvas.test/api/lti/courses/1/line_items/5/", false},
+		{"https://canvas.test/api/lti/courses/1/line_items", false},
+		{"https://canvas.test/api/lti/courses/1/line_items_other/5", false},
+		{"https://canvas.test/api/lti/courses/2/line_items/5", false},
+		{"https://canvas.test/api/lti/courses/1/line_items/../../2/line_items/5", false},
+		{"http://canvas.test/api/lti/courses/1/line_items/5", false},
+		{"https://evil.test/api/lti/courses/1/line_items/5", false},
+		{"https://user@canvas.test/api/lti/courses/1/line_items/5", false},
+		{"https://canvas.test/api/lti/courses/1/line_items/5?x=1", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := withinUrl(base, tt.url); got != tt.want {
+			t.Errorf("withinUrl(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	if withinUrl("", "https://canvas.test/line_items/5") {
+		t.Error("withinUrl without a service URL should be false")
+	}
+}
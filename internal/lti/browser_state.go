@@ -0,0 +1,139 @@
+package lti
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"net/http"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	stateCookiePrefix = "lti_state_"
+	storageKeyPrefix  = "lti_state_"
+)
+
+// StorageCheckRequired is returned by LtiLaunch when the state cookie was
+// blocked and the state has to be read back from the platform storage by the
+// browser before the launch can continue.
+type StorageCheckRequired struct {
+	Page dto.LtiStorageCheckPage
+}
+
+func (e *StorageCheckRequired) Error() string {
+	return "state cookie missing, platform storage check required"
+}
+
+// setStateCookie : Private method to bind the login state to the browser with a signed cookie.
+// Partitioned keeps the cookie usable inside the platform iframe when third-party cookies are partitioned.
+func (s *service) setStateCookie(c *fiber.Ctx, state string) {
+	cookie := &http.Cookie{
+		Name:        stateCookiePrefix + state,
+		Value:       s.signState(state),
+		Path:        "/",
+		MaxAge:      int(nonceTTL.Seconds()),
+		Secure:      true,
+		HttpOnly:    true,
+		SameSite:    http.SameSiteNoneMode,
+		Partitioned: true,
+	}
+	c.Append(fiber.HeaderSetCookie, cookie.String())
+}
+
+// clearStateCookie : Private method to remove the state cookie once the launch is complete
+func (s *service) clearStateCookie(c *fiber.Ctx, state string) {
+	cookie := &http.Cookie{
+		Name:        stateCookiePrefix + state,
+		Path:        "/",
+		MaxAge:      -1,
+		Secure:      true,
+		HttpOnly:    true,
+		SameSite:    http.SameSiteNoneMode,
+		Partitioned: true,
+	}
+	c.Append(fiber.HeaderSetCookie, cookie.String())
+}
+
+// verifyBrowserState : Private method to check that the launch comes from the browser that started the login,
+// using the state cookie or, when cookies are blocked, the value read back from the platform storage
+func (s *service) verifyBrowserState(c *fiber.Ctx, request *dto.LtiLaunchRequest) error {
+	if request.State == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "missing state")
+	}
+
+	if s.validStateSignature(request.State, c.Cookies(stateCookiePrefix+request.State)) {
+		return nil
+	}
+
+	if request.LtiStorageTarget == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "state is not bound to this browser")
+	}
+
+	if request.LtiStorageValue == "" {
+		registration, err := findTokenRegistration(s.registrations, request.IdToken)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+		platformOrigin, err := origin(registration.AuthLoginUrl)
+		if err != nil {
+			return err
+		}
+
+		return &StorageCheckRequired{
+			Page: dto.LtiStorageCheckPage{
+				LaunchUrl:      s.cfg.LtiConfig.LaunchUrl,
+				PlatformOrigin: platformOrigin,
+				StorageTarget:  request.LtiStorageTarget,
+				StorageKey:     storageKeyPrefix + request.State,
+				MessageId:      uuid.New().String(),
+				IdToken:        request.IdToken,
+				State:          request.State,
+			},
+		}
+	}
+
+	// the storage value is only trusted when our own check page posted it,
+	// a cross-site form post could carry any value
+	toolOrigin, err := origin(s.cfg.LtiConfig.LaunchUrl)
+	if err != nil {
+		return err
+	}
+	if c.Get(fiber.HeaderOrigin) != toolOrigin {
+		return fiber.NewError(fiber.StatusUnauthorized, "platform storage value must be posted from the tool origin")
+	}
+	if !s.validStateSignature(request.State, request.LtiStorageValue) {
+		return fiber.NewError(fiber.StatusUnauthorized, "state is not bound to this browser")
+	}
+
+	return nil
+}
+
+// signState : Private method to compute the HMAC of a state
+func (s *service) signState(state string) string {
+	mac := hmac.New(sha256.New, s.stateSecret)
+	mac.Write([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *service) validStateSignature(state string, signature string) bool {
+	if signature == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(s.signState(state)))
+}
+
+// origin : Return the scheme and host of a URL
+func origin(rawUrl string) (string, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid url: %s", rawUrl)
+	}
+
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host), nil
+}
@@ -0,0 +1,211 @@
+package lti
+
+import (
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"slices"
+	"time"
+)
+
+const (
+	ClaimMessageType         = "https://purl.imsglobal.org/spec/lti/claim/message_type"
+	ClaimVersion             = "https://purl.imsglobal.org/spec/lti/claim/version"
+	ClaimDeploymentId        = "https://purl.imsglobal.org/spec/lti/claim/deployment_id"
+	ClaimTargetLinkUri       = "https://purl.imsglobal.org/spec/lti/claim/target_link_uri"
+	ClaimResourceLink        = "https://purl.imsglobal.org/spec/lti/claim/resource_link"
+	ClaimRoles               = "https://purl.imsglobal.org/spec/lti/claim/roles"
+	ClaimContext             = "https://purl.imsglobal.org/spec/lti/claim/context"
+	ClaimDeepLinkingSettings = "https://purl.imsglobal.org/spec/lti-dl/claim/deep_linking_settings"
+	ClaimNotice              = "https://purl.imsglobal.org/spec/lti/claim/notice"
+
+	ltiVersion = "1.3.0"
+	// clockSkew is the tolerated difference between the platform and tool clocks
+	clockSkew = 1 * time.Minute
+	// maxTokenAge rejects tokens issued long before they reach the tool
+	maxTokenAge = 1 * time.Hour
+	// maxIdLength is the longest deployment, user, context and resource link id allowed by LTI core
+	maxIdLength = 255
+)
+
+// claimsValidator collects every failing claim of a platform JWT instead of stopping at the first one
+type claimsValidator struct {
+	claims map[string]any
+	errors []dto.ClaimError
+}
+
+// claimsRule is a set of checks applied to the claims of a platform JWT
+type claimsRule func(v *claimsValidator)
+
+// fail : Record a failing claim
+func (v *claimsValidator) fail(claim string, format string, args ...any) {
+	v.errors = append(v.errors, dto.ClaimError{
+		Claim:   claim,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// err : Return the failing claims as a ClaimsValidationError, nil when every claim passed
+func (v *claimsValidator) err(token string) error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	return &dto.ClaimsValidationError{
+		Token:  token,
+		Errors: v.errors,
+	}
+}
+
+// requireString : Check that claim of object is a non-empty string of at most maxLength characters, 0 for any length
+func (v *claimsValidator) requireString(object map[string]any, name string, claim string, maxLength int) string {
+	value, ok := object[claim]
+	if !ok {
+		v.fail(name, "is required")
+		return ""
+	}
+
+	s, ok := value.(string)
+	switch {
+	case !ok:
+		v.fail(name, "must be a string")
+	case s == "":
+		v.fail(name, "must not be empty")
+	case maxLength > 0 && len(s) > maxLength:
+		v.fail(name, "must not exceed %d characters", maxLength)
+	}
+
+	return s
+}
+
+// optionalString : Check claim of object like requireString when it is present
+func (v *claimsValidator) optionalString(object map[string]any, name string, claim string, maxLength int) {
+	if _, ok := object[claim]; ok {
+		v.requireString(object, name, claim, maxLength)
+	}
+}
+
+// requireObject : Check that claim of object is a JSON object
+func (v *claimsValidator) requireObject(object map[string]any, name string, claim string) map[string]any {
+	value, ok := object[claim]
+	if !ok {
+		v.fail(name, "is required")
+		return nil
+	}
+
+	o, ok := value.(map[string]any)
+	if !ok {
+		v.fail(name, "must be an object")
+	}
+
+	return o
+}
+
+// requireStrings : Check that claim of object is an array of strings, with at least one when nonEmpty
+func (v *claimsValidator) requireStrings(object map[string]any, name string, claim string, nonEmpty bool) {
+	value, ok := object[claim]
+	if !ok {
+		v.fail(name, "is required")
+		return
+	}
+
+	items, ok := value.([]any)
+	if !ok || slices.ContainsFunc(items, func(item any) bool { _, ok := item.(string); return !ok }) {
+		v.fail(name, "must be an array of strings")
+		return
+	}
+	if nonEmpty && len(items) == 0 {
+		v.fail(name, "must not be empty")
+	}
+}
+
+// requireVersion : Check that the token is an LTI 1.3 message
+func (v *claimsValidator) requireVersion() {
+	if version, ok := v.claims[ClaimVersion]; !ok {
+		v.fail(ClaimVersion, "is required")
+	} else if version != ltiVersion {
+		v.fail(ClaimVersion, "must be %s", ltiVersion)
+	}
+}
+
+// securityClaims : The IMS Security Framework checks of a JWT sent by the platform of registration
+func securityClaims(registration *dto.LtiRegistration, now time.Time) claimsRule {
+	return func(v *claimsValidator) {
+		if iss, _ := v.claims["iss"].(string); iss != registration.Issuer {
+			v.fail("iss", "must be the platform issuer %s", registration.Issuer)
+		}
+
+		audiences, _ := v.claims["aud"].([]string)
+		if !slices.Contains(audiences, registration.ClientId) {
+			v.fail("aud", "must contain the client_id %s", registration.ClientId)
+		}
+		azp, hasAzp := v.claims["azp"]
+		switch {
+		case hasAzp && azp != registration.ClientId:
+			v.fail("azp", "must be the client_id %s", registration.ClientId)
+		case !hasAzp && len(audiences) > 1:
+			v.fail("azp", "is required when aud has multiple values")
+		}
+
+		if exp, ok := v.claims["exp"].(time.Time); !ok {
+			v.fail("exp", "is required")
+		} else if now.After(exp.Add(clockSkew)) {
+			v.fail("exp", "token expired at %s", exp.UTC().Format(time.RFC3339))
+		}
+
+		if iat, ok := v.claims["iat"].(time.Time); !ok {
+			v.fail("iat", "is required")
+		} else if iat.After(now.Add(clockSkew)) {
+			v.fail("iat", "token is issued in the future, at %s", iat.UTC().Format(time.RFC3339))
+		} else if now.Sub(iat) > maxTokenAge {
+			v.fail("iat", "token was issued more than %s ago", maxTokenAge)
+		}
+
+		if nbf, ok := v.claims["nbf"].(time.Time); ok && nbf.After(now.Add(clockSkew)) {
+			v.fail("nbf", "token is not valid before %s", nbf.UTC().Format(time.RFC3339))
+		}
+	}
+}
+
+// launchClaims : The LTI core required claims of an id_token, per message type
+func launchClaims(v *claimsValidator) {
+	v.requireString(v.claims, "nonce", "nonce", 0)
+	v.optionalString(v.claims, "sub", "sub", maxIdLength)
+	v.requireVersion()
+	v.requireString(v.claims, ClaimDeploymentId, ClaimDeploymentId, maxIdLength)
+	v.requireStrings(v.claims, ClaimRoles, ClaimRoles, false)
+
+	if _, ok := v.claims[ClaimContext]; ok {
+		if context := v.requireObject(v.claims, ClaimContext, ClaimContext); context != nil {
+			v.requireString(context, ClaimContext+".id", "id", maxIdLength)
+		}
+	}
+
+	switch messageType := v.requireString(v.claims, ClaimMessageType, ClaimMessageType, 0); messageType {
+	case MessageTypeResourceLink:
+		v.requireString(v.claims, ClaimTargetLinkUri, ClaimTargetLinkUri, 0)
+		if resourceLink := v.requireObject(v.claims, ClaimResourceLink, ClaimResourceLink); resourceLink != nil {
+			v.requireString(resourceLink, ClaimResourceLink+".id", "id", maxIdLength)
+		}
+	case MessageTypeDeepLinkingRequest:
+		if settings := v.requireObject(v.claims, ClaimDeepLinkingSettings, ClaimDeepLinkingSettings); settings != nil {
+			v.requireString(settings, ClaimDeepLinkingSettings+".deep_link_return_url", "deep_link_return_url", 0)
+			v.requireStrings(settings, ClaimDeepLinkingSettings+".accept_types", "accept_types", true)
+			v.requireStrings(settings, ClaimDeepLinkingSettings+".accept_presentation_document_targets", "accept_presentation_document_targets", true)
+		}
+	case "":
+	default:
+		v.fail(ClaimMessageType, "unsupported message type %s", messageType)
+	}
+}
+
+// noticeClaims : The required claims of a platform notice, jti is needed to detect replayed notices
+func noticeClaims(v *claimsValidator) {
+	v.requireString(v.claims, "jti", "jti", 0)
+	v.requireVersion()
+	v.requireString(v.claims, ClaimDeploymentId, ClaimDeploymentId, maxIdLength)
+
+	if notice := v.requireObject(v.claims, ClaimNotice, ClaimNotice); notice != nil {
+		v.requireString(notice, ClaimNotice+".id", "id", 0)
+		v.requireString(notice, ClaimNotice+".type", "type", 0)
+	}
+}
@@ -0,0 +1,228 @@
+package lti
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-lti/internal/domain/dto"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+var testRegistration = &dto.LtiRegistration{
+	Issuer:   "https://canvas.test",
+	ClientId: "10000000000001",
+}
+
+// validateClaims : Run rules on claims decoded like a platform JWT and return the names of the failing claims
+func validateClaims(t *testing.T, claims map[string]any, rules ...claimsRule) []string {
+	t.Helper()
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.New()
+	if err := json.Unmarshal(data, token); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := token.AsMap(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator := &claimsValidator{claims: decoded}
+	for _, rule := range rules {
+		rule(validator)
+	}
+
+	err = validator.err("id_token")
+	if err == nil {
+		return nil
+	}
+
+	var claimsErr *dto.ClaimsValidationError
+	if !errors.As(err, &claimsErr) {
+		t.Fatalf("err = %T, want *dto.ClaimsValidationError", err)
+	}
+	if claimsErr.Token != "id_token" {
+		t.Errorf("Token = %q, want id_token", claimsErr.Token)
+	}
+
+	names := make([]string, 0, len(claimsErr.Errors))
+	for _, e := range claimsErr.Errors {
+		names = append(names, e.Claim)
+	}
+
+	return names
+}
+
+func securityPayload(now time.Time) map[string]any {
+	return map[string]any{
+		"iss": testRegistration.Issuer,
+		"aud": testRegistration.ClientId,
+		"exp": now.Add(5 * time.Minute).Unix(),
+		"iat": now.Add(-10 * time.Second).Unix(),
+	}
+}
+
+func TestSecurityClaims(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name   string
+		mutate func(claims map[string]any)
+		want   []string
+	}{
+		{"valid", func(claims map[string]any) {}, nil},
+		{"other issuer", func(claims map[string]any) { claims["iss"] = "https://evil.test" }, []string{"iss"}},
+		{"missing issuer", func(claims map[string]any) { delete(claims, "iss") }, []string{"iss"}},
+		{"other audience", func(claims map[string]any) { claims["aud"] = "20000000000002" }, []string{"aud"}},
+		{"audience list with client", func(claims map[string]any) {
+			claims["aud"] = []string{testRegistration.ClientId}
+		}, nil},
+		{"several audiences without azp", func(claims map[string]any) {
+			claims["aud"] = []string{"other", testRegistration.ClientId}
+		}, []string{"azp"}},
+		{"several audiences with azp", func(claims map[string]any) {
+			claims["aud"] = []string{"other", testRegistration.ClientId}
+			claims["azp"] = testRegistration.ClientId
+		}, nil},
+		{"other azp", func(claims map[string]any) { claims["azp"] = "other" }, []string{"azp"}},
+		{"missing exp", func(claims map[string]any) { delete(claims, "exp") }, []string{"exp"}},
+		{"expired", func(claims map[string]any) { claims["exp"] = now.Add(-2 * time.Minute).Unix() }, []string{"exp"}},
+		{"expired within clock skew", func(claims map[string]any) { claims["exp"] = now.Add(-30 * time.Second).Unix() }, nil},
+		{"missing iat", func(claims map[string]any) { delete(claims, "iat") }, []string{"iat"}},
+		{"issued in the future", func(claims map[string]any) { claims["iat"] = now.Add(2 * time.Minute).Unix() }, []string{"iat"}},
+		{"issued in the future within clock skew", func(claims map[string]any) { claims["iat"] = now.Add(30 * time.Second).Unix() }, nil},
+		{"issued too long ago", func(claims map[string]any) { claims["iat"] = now.Add(-2 * time.Hour).Unix() }, []string{"iat"}},
+		{"not yet valid", func(claims map[string]any) { claims["nbf"] = now.Add(2 * time.Minute).Unix() }, []string{"nbf"}},
+		{"valid nbf", func(claims map[string]any) { claims["nbf"] = now.Add(-time.Minute).Unix() }, nil},
+		{"every claim wrong", func(claims map[string]any) {
+			claims["iss"] = "https://evil.test"
+			claims["aud"] = "other"
+			delete(claims, "exp")
+			delete(claims, "iat")
+		}, []string{"iss", "aud", "exp", "iat"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := securityPayload(now)
+			tt.mutate(claims)
+
+			if got := validateClaims(t, claims, securityClaims(testRegistration, now)); !slices.Equal(got, tt.want) {
+				t.Errorf("failing claims = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func resourceLinkPayload() map[string]any {
+	return map[string]any{
+		"nonce":              "n-1",
+		"sub":                "user-1",
+		ClaimVersion:         "1.3.0",
+		ClaimMessageType:     MessageTypeResourceLink,
+		ClaimDeploymentId:    "1:abc",
+		ClaimRoles:           []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"},
+		ClaimTargetLinkUri:   "https://tool.test/launch",
+		ClaimResourceLink:    map[string]any{"id": "rl-1"},
+		ClaimContext:         map[string]any{"id": "course-1"},
+		"https://other.test": "kept",
+	}
+}
+
+func deepLinkingPayload() map[string]any {
+	claims := resourceLinkPayload()
+	claims[ClaimMessageType] = MessageTypeDeepLinkingRequest
+	delete(claims, ClaimResourceLink)
+	delete(claims, ClaimTargetLinkUri)
+	claims[ClaimDeepLinkingSettings] = map[string]any{
+		"deep_link_return_url":                 "https://canvas.test/deep_link",
+		"accept_types":                         []string{"ltiResourceLink"},
+		"accept_presentation_document_targets": []string{"iframe"},
+	}
+
+	return claims
+}
+
+func TestLaunchClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload func() map[string]any
+		mutate  func(claims map[string]any)
+		want    []string
+	}{
+		{"valid resource link", resourceLinkPayload, func(claims map[string]any) {}, nil},
+		{"valid deep linking", deepLinkingPayload, func(claims map[string]any) {}, nil},
+		{"anonymous launch", resourceLinkPayload, func(claims map[string]any) { delete(claims, "sub") }, nil},
+		{"empty roles", resourceLinkPayload, func(claims map[string]any) { claims[ClaimRoles] = []string{} }, nil},
+		{"without context", resourceLinkPayload, func(claims map[string]any) { delete(claims, ClaimContext) }, nil},
+		{"missing nonce", resourceLinkPayload, func(claims map[string]any) { delete(claims, "nonce") }, []string{"nonce"}},
+		{"empty nonce", resourceLinkPayload, func(claims map[string]any) { claims["nonce"] = "" }, []string{"nonce"}},
+		{"sub too long", resourceLinkPayload, func(claims map[string]any) {
+			claims["sub"] = strings.Repeat("u", maxIdLength+1)
+		}, []string{"sub"}},
+		{"missing version", resourceLinkPayload, func(claims map[string]any) { delete(claims, ClaimVersion) }, []string{ClaimVersion}},
+		{"other version", resourceLinkPayload, func(claims map[string]any) { claims[ClaimVersion] = "1.1" }, []string{ClaimVersion}},
+		{"missing deployment", resourceLinkPayload, func(claims map[string]any) {
+			delete(claims, ClaimDeploymentId)
+		}, []string{ClaimDeploymentId}},
+		{"numeric deployment", resourceLinkPayload, func(claims map[string]any) {
+			claims[ClaimDeploymentId] = 12
+		}, []string{ClaimDeploymentId}},
+		{"missing roles", resourceLinkPayload, func(claims map[string]any) { delete(claims, ClaimRoles) }, []string{ClaimRoles}},
+		{"roles not strings", resourceLinkPayload, func(claims map[string]any) { claims[ClaimRoles] = []any{"Learner", 1} }, []string{ClaimRoles}},
+		{"roles not a list", resourceLinkPayload, func(claims map[string]any) { claims[ClaimRoles] = "Learner" }, []string{ClaimRoles}},
+		{"context without id", resourceLinkPayload, func(claims map[string]any) {
+			claims[ClaimContext] = map[string]any{"title": "Course"}
+		}, []string{ClaimContext + ".id"}},
+		{"context not an object", resourceLinkPayload, func(claims map[string]any) {
+			claims[ClaimContext] = "course-1"
+		}, []string{ClaimContext}},
+		{"missing message type", resourceLinkPayload, func(claims map[string]any) {
+			delete(claims, ClaimMessageType)
+		}, []string{ClaimMessageType}},
+		{"unsupported message type", resourceLinkPayload, func(claims map[string]any) {
+			claims[ClaimMessageType] = "LtiSubmissionReviewRequest"
+		}, []string{ClaimMessageType}},
+		{"resource link without target and link", resourceLinkPayload, func(claims map[string]any) {
+			delete(claims, ClaimTargetLinkUri)
+			delete(claims, ClaimResourceLink)
+		}, []string{ClaimTargetLinkUri, ClaimResourceLink}},
+		{"resource link without id", resourceLinkPayload, func(claims map[string]any) {
+			claims[ClaimResourceLink] = map[string]any{"title": "Quiz"}
+		}, []string{ClaimResourceLink + ".id"}},
+		{"deep linking without settings", deepLinkingPayload, func(claims map[string]any) {
+			delete(claims, ClaimDeepLinkingSettings)
+		}, []string{ClaimDeepLinkingSettings}},
+		{"deep linking with incomplete settings", deepLinkingPayload, func(claims map[string]any) {
+			claims[ClaimDeepLinkingSettings] = map[string]any{"accept_types": []string{}}
+		}, []string{
+			ClaimDeepLinkingSettings + ".deep_link_return_url",
+			ClaimDeepLinkingSettings + ".accept_types",
+			ClaimDeepLinkingSettings + ".accept_presentation_document_targets",
+		}},
+		{"every failure is reported", resourceLinkPayload, func(claims map[string]any) {
+			delete(claims, "nonce")
+			claims[ClaimVersion] = "1.1"
+			delete(claims, ClaimRoles)
+		}, []string{"nonce", ClaimVersion, ClaimRoles}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := tt.payload()
+			tt.mutate(claims)
+
+			if got := validateClaims(t, claims, launchClaims); !slices.Equal(got, tt.want) {
+				t.Errorf("failing claims = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package lti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/lib/store"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	MessageTypeResourceLink        = "LtiResourceLinkRequest"
+	MessageTypeDeepLinkingRequest  = "LtiDeepLinkingRequest"
+	MessageTypeDeepLinkingResponse = "LtiDeepLinkingResponse"
+
+	ContentItemResourceLink = "ltiResourceLink"
+	ContentItemLink         = "link"
+	ContentItemFile         = "file"
+	ContentItemHtml         = "html"
+	ContentItemImage        = "image"
+)
+
+type deepLinkingLaunch struct {
+	Claims       *dto.LtiJwtTokenClaims `json:"claims"`
+	Registration *dto.LtiRegistration   `json:"registration"`
+}
+
+// ContentItemBuilder builds a Deep Linking content item step by step
+type ContentItemBuilder struct {
+	item dto.LtiContentItem
+}
+
+// ResourceLink starts an ltiResourceLink content item launching the given url
+func ResourceLink(url string) *ContentItemBuilder {
+	return &ContentItemBuilder{item: dto.LtiContentItem{Type: ContentItemResourceLink, Url: url}}
+}
+
+// Link starts a link content item pointing at the given url
+func Link(url string) *ContentItemBuilder {
+	return &ContentItemBuilder{item: dto.LtiContentItem{Type: ContentItemLink, Url: url}}
+}
+
+// File starts a file content item with the given download url and media type
+func File(url string, mediaType string) *ContentItemBuilder {
+	return &ContentItemBuilder{item: dto.LtiContentItem{Type: ContentItemFile, Url: url, MediaType: mediaType}}
+}
+
+// Html starts an html fragment content item
+func Html(html string) *ContentItemBuilder {
+	return &ContentItemBuilder{item: dto.LtiContentItem{Type: ContentItemHtml, Html: html}}
+}
+
+// Image starts an image content item with its dimensions
+func Image(url string, width int, height int) *ContentItemBuilder {
+	return &ContentItemBuilder{item: dto.LtiContentItem{Type: ContentItemImage, Url: url, Width: width, Height: height}}
+}
+
+func (b *ContentItemBuilder) Title(title string) *ContentItemBuilder {
+	b.item.Title = title
+	return b
+}
+
+func (b *ContentItemBuilder) Text(text string) *ContentItemBuilder {
+	b.item.Text = text
+	return b
+}
+
+func (b *ContentItemBuilder) Icon(url string, width int, height int) *ContentItemBuilder {
+	b.item.Icon = &dto.LtiContentImage{Url: url, Width: width, Height: height}
+	return b
+}
+
+func (b *ContentItemBuilder) Thumbnail(url string, width int, height int) *ContentItemBuilder {
+	b.item.Thumbnail = &dto.LtiContentImage{Url: url, Width: width, Height: height}
+	return b
+}
+
+func (b *ContentItemBuilder) ExpiresAt(expiresAt time.Time) *ContentItemBuilder {
+	b.item.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	return b
+}
+
+func (b *ContentItemBuilder) Embed(html string) *ContentItemBuilder {
+	b.item.Embed = &dto.LtiContentEmbed{Html: html}
+	return b
+}
+
+func (b *ContentItemBuilder) Window(targetName string, width int, height int) *ContentItemBuilder {
+	b.item.Window = &dto.LtiContentWindow{TargetName: targetName, Width: width, Height: height}
+	return b
+}
+
+func (b *ContentItemBuilder) Iframe(src string, width int, height int) *ContentItemBuilder {
+	b.item.Iframe = &dto.LtiContentIframe{Src: src, Width: width, Height: height}
+	return b
+}
+
+// LineItem asks the platform to create a gradebook column for an ltiResourceLink
+func (b *ContentItemBuilder) LineItem(label string, scoreMaximum float64, resourceId string, tag string) *ContentItemBuilder {
+	b.item.LineItem = &dto.LtiContentLineItem{
+		Label:        label,
+		ScoreMaximum: scoreMaximum,
+		ResourceId:   resourceId,
+		Tag:          tag,
+	}
+	return b
+}
+
+func (b *ContentItemBuilder) Available(start time.Time, end time.Time) *ContentItemBuilder {
+	b.item.Available = timeWindow(start, end)
+	return b
+}
+
+func (b *ContentItemBuilder) Submission(start time.Time, end time.Time) *ContentItemBuilder {
+	b.item.Submission = timeWindow(start, end)
+	return b
+}
+
+// Custom adds a custom parameter passed back on launches of an ltiResourceLink
+func (b *ContentItemBuilder) Custom(key string, value string) *ContentItemBuilder {
+	if b.item.Custom == nil {
+		b.item.Custom = make(map[string]string)
+	}
+	b.item.Custom[key] = value
+	return b
+}
+
+func (b *ContentItemBuilder) Build() dto.LtiContentItem {
+	return b.item
+}
+
+// StartDeepLinking : Keep a validated deep linking launch until the content items are selected
+func (s *service) StartDeepLinking(c *fiber.Ctx, claims *dto.LtiJwtTokenClaims) (*dto.LtiDeepLinkingLaunch, error) {
+	if claims.MessageType != MessageTypeDeepLinkingRequest {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "launch is not a deep linking request")
+	}
+	if claims.DeepLinkingSettings.DeepLinkReturnUrl == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "missing deep_link_return_url")
+	}
+
+	registration, err := s.registrations.FindDeployment(claims.Iss, claimsClientId(claims), claims.DeploymentID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	launch, err := json.Marshal(&deepLinkingLaunch{
+		Claims:       claims,
+		Registration: registration,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	if err := s.store.Set(c.Context(), deepLinkKeyPrefix+id, string(launch), deepLinkTTL); err != nil {
+		return nil, err
+	}
+
+	return &dto.LtiDeepLinkingLaunch{
+		DeepLinkingId: id,
+		Settings:      claims.DeepLinkingSettings,
+	}, nil
+}
+
+// DeepLinkingResponse : Sign the LtiDeepLinkingResponse for the selected content items
+func (s *service) DeepLinkingResponse(c *fiber.Ctx, request *dto.LtiDeepLinkingResponseRequest) (*dto.LtiDeepLinkingForm, error) {
+	key := deepLinkKeyPrefix + request.DeepLinkingId
+	data, err := s.store.Get(c.Context(), key)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid deep_linking_id")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var launch deepLinkingLaunch
+	if err := json.Unmarshal([]byte(data), &launch); err != nil {
+		return nil, err
+	}
+
+	settings := launch.Claims.DeepLinkingSettings
+	if err := validateContentItems(&settings, request.ContentItems); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// the launch can only be answered once, concurrent submits must not both get a signed response
+	_, err = s.store.Consume(c.Context(), key)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid deep_linking_id")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token := jwt.New()
+	token.Set(jwt.IssuerKey, launch.Registration.ClientId)
+	token.Set(jwt.AudienceKey, launch.Registration.Issuer)
+	token.Set(jwt.IssuedAtKey, time.Now().Unix())
+	token.Set(jwt.ExpirationKey, time.Now().Add(5*time.Minute).Unix())
+	token.Set(jwt.JwtIDKey, uuid.New().String())
+	token.Set("nonce", uuid.New().String())
+	token.Set("https://purl.imsglobal.org/spec/lti/claim/message_type", MessageTypeDeepLinkingResponse)
+	token.Set("https://purl.imsglobal.org/spec/lti/claim/version", "1.3.0")
+	token.Set("https://purl.imsglobal.org/spec/lti/claim/deployment_id", launch.Claims.DeploymentID)
+	token.Set("https://purl.imsglobal.org/spec/lti-dl/claim/content_items", request.ContentItems)
+	if settings.Data != "" {
+		token.Set("https://purl.imsglobal.org/spec/lti-dl/claim/data", settings.Data)
+	}
+	for claim, value := range map[string]string{
+		"https://purl.imsglobal.org/spec/lti-dl/claim/msg":      request.Msg,
+		"https://purl.imsglobal.org/spec/lti-dl/claim/log":      request.Log,
+		"https://purl.imsglobal.org/spec/lti-dl/claim/errormsg": request.ErrorMsg,
+		"https://purl.imsglobal.org/spec/lti-dl/claim/errorlog": request.ErrorLog,
+	} {
+		if value != "" {
+			token.Set(claim, value)
+		}
+	}
+
+	signedToken, err := s.keys.Sign(launch.Registration.KeyId, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LtiDeepLinkingForm{
+		ReturnUrl: settings.DeepLinkReturnUrl,
+		Jwt:       signedToken,
+	}, nil
+}
+
+// validateContentItems : Private method to check content items against the deep linking settings of the launch
+func validateContentItems(settings *dto.LtiDeepLinkingSettings, items []dto.LtiContentItem) error {
+	if len(items) > 1 && !settings.AcceptMultiple {
+		return fmt.Errorf("platform accepts a single content item, got %d", len(items))
+	}
+
+	for i, item := range items {
+		if len(settings.AcceptTypes) > 0 && !slices.Contains(settings.AcceptTypes, item.Type) {
+			return fmt.Errorf("content_items[%d]: type %q is not accepted", i, item.Type)
+		}
+		if item.LineItem != nil && !settings.AcceptLineItem {
+			return fmt.Errorf("content_items[%d]: platform does not accept line items", i)
+		}
+		if item.LineItem != nil && item.Type != ContentItemResourceLink {
+			return fmt.Errorf("content_items[%d]: line items are only allowed on %s", i, ContentItemResourceLink)
+		}
+
+		switch item.Type {
+		case ContentItemResourceLink, ContentItemLink, ContentItemFile, ContentItemImage:
+			if item.Url == "" && item.Type != ContentItemResourceLink {
+				return fmt.Errorf("content_items[%d]: url is required", i)
+			}
+		case ContentItemHtml:
+			if item.Html == "" {
+				return fmt.Errorf("content_items[%d]: html is required", i)
+			}
+		default:
+			return fmt.Errorf("content_items[%d]: unknown type %q", i, item.Type)
+		}
+	}
+
+	return nil
+}
+
+// claimsClientId : Private method to get the client_id a launch was issued for
+func claimsClientId(claims *dto.LtiJwtTokenClaims) string {
+	if claims.Azp != "" {
+		return claims.Azp
+	}
+	if len(claims.Aud) > 0 {
+		return claims.Aud[0]
+	}
+
+	return ""
+}
+
+func timeWindow(start time.Time, end time.Time) *dto.LtiContentTimeWindow {
+	window := &dto.LtiContentTimeWindow{}
+	if !start.IsZero() {
+		window.StartDateTime = start.UTC().Format(time.RFC3339)
+	}
+	if !end.IsZero() {
+		window.EndDateTime = end.UTC().Format(time.RFC3339)
+	}
+
+	return window
+}
@@ -0,0 +1,216 @@
+package lti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// toolScopes are the service scopes requested during dynamic registration
+var toolScopes = []string{
+	ScopeLineItem,
+	ScopeLineItemReadonly,
+	ScopeResultReadonly,
+	ScopeScore,
+	ScopeContextMembershipReadonly,
+	ScopeNoticeHandlers,
+}
+
+// toolClaims are the identity claims the tool asks the platform to include in launches
+var toolClaims = []string{"iss", "sub", "name", "given_name", "family_name", "email", "picture", "locale"}
+
+// DynamicRegistration : Register the tool with a platform using LTI Dynamic Registration and store the result
+func (s *service) DynamicRegistration(c *fiber.Ctx, request *dto.LtiDynamicRegistrationRequest) (*dto.LtiRegistration, error) {
+	// without allowed hosts anyone could register a platform and launch with their own keys
+	if len(s.cfg.LtiConfig.RegistrationHosts) == 0 {
+		return nil, fiber.ErrNotFound
+	}
+	if request.OpenidConfiguration == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "openid_configuration is required")
+	}
+
+	configUrl, err := s.checkPlatformUrl(c.Context(), request.OpenidConfiguration)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("openid_configuration: %s", err))
+	}
+
+	headers := map[string]string{
+		fiber.HeaderAccept: fiber.MIMEApplicationJSON,
+	}
+	if request.RegistrationToken != "" {
+		headers[fiber.HeaderAuthorization] = fmt.Sprintf("Bearer %s", request.RegistrationToken)
+	}
+
+	var openidConfig dto.OpenidConfiguration
+	err = s.httpClient.Call(c.Context(), http.MethodGet, configUrl.String(), headers, nil, &openidConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch openid configuration: %w", err)
+	}
+	if openidConfig.Issuer == "" || openidConfig.RegistrationEndpoint == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "openid configuration is missing issuer or registration_endpoint")
+	}
+	if err := checkPlatformConfiguration(configUrl, &openidConfig); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if _, err := s.checkPlatformUrl(c.Context(), openidConfig.RegistrationEndpoint); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("registration_endpoint: %s", err))
+	}
+
+	toolRegistration, err := s.buildToolRegistration(&openidConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var registered dto.LtiToolRegistration
+	err = s.httpClient.Call(c.Context(), http.MethodPost, openidConfig.RegistrationEndpoint, headers, toolRegistration, &registered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register tool: %w", err)
+	}
+	if registered.ClientId == "" {
+		return nil, fiber.NewError(fiber.StatusBadGateway, "platform did not return a client_id")
+	}
+
+	registration := &dto.LtiRegistration{
+		Issuer:       openidConfig.Issuer,
+		ClientId:     registered.ClientId,
+		AuthLoginUrl: openidConfig.AuthorizationEndpoint,
+		AuthTokenUrl: openidConfig.TokenEndpoint,
+		JwksUrl:      openidConfig.JwksUri,
+	}
+	if deploymentId := registered.ToolConfiguration.DeploymentId; deploymentId != "" {
+		registration.DeploymentIds = []string{deploymentId}
+	}
+
+	if err := s.registrations.Save(registration); err != nil {
+		return nil, err
+	}
+
+	return registration, nil
+}
+
+// checkPlatformUrl : Private method to accept only https urls on an allowed platform host with a public address
+func (s *service) checkPlatformUrl(ctx context.Context, rawUrl string) (*url.URL, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return nil, errors.New("invalid url")
+	}
+	if u.Scheme != "https" {
+		return nil, errors.New("url must use https")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if !allowedHost(s.cfg.LtiConfig.RegistrationHosts, host) {
+		return nil, fmt.Errorf("host %s is not allowed to register", host)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s", host)
+	}
+	for _, addr := range addrs {
+		addr = addr.Unmap()
+		if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+			addr.IsMulticast() || addr.IsUnspecified() {
+			return nil, fmt.Errorf("host %s resolves to a non-public address", host)
+		}
+	}
+
+	return u, nil
+}
+
+// allowedHost : Report whether host matches one of the patterns, an exact host or *.example.com for its subdomains
+func allowedHost(patterns []string, host string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+			return domain != "" && !strings.Contains(domain, "*") && strings.HasSuffix(host, "."+domain)
+		}
+		// any other wildcard is a configuration mistake and matches nothing
+		return pattern != "" && !strings.Contains(pattern, "*") && host == pattern
+	})
+}
+
+// checkPlatformConfiguration : Private method to check that the issuer is the origin of the configuration url and
+// that the platform endpoints use https
+func checkPlatformConfiguration(configUrl *url.URL, openidConfig *dto.OpenidConfiguration) error {
+	issuer, err := url.Parse(openidConfig.Issuer)
+	if err != nil || issuer.Scheme != configUrl.Scheme || !strings.EqualFold(issuer.Host, configUrl.Host) {
+		return fmt.Errorf("issuer %s does not match the openid configuration host %s", openidConfig.Issuer, configUrl.Host)
+	}
+
+	for name, endpoint := range map[string]string{
+		"authorization_endpoint": openidConfig.AuthorizationEndpoint,
+		"token_endpoint":         openidConfig.TokenEndpoint,
+		"jwks_uri":               openidConfig.JwksUri,
+	} {
+		if u, err := url.Parse(endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("%s must be an https url", name)
+		}
+	}
+
+	return nil
+}
+
+// buildToolRegistration : Private method to describe the tool to the platform, limited to what it supports
+func (s *service) buildToolRegistration(openidConfig *dto.OpenidConfiguration) (*dto.LtiToolRegistration, error) {
+	launchUrl, err := url.Parse(s.cfg.LtiConfig.LaunchUrl)
+	if err != nil || launchUrl.Host == "" {
+		return nil, fmt.Errorf("invalid launch url: %s", s.cfg.LtiConfig.LaunchUrl)
+	}
+
+	scopes := toolScopes
+	if len(openidConfig.ScopesSupported) > 0 {
+		scopes = slices.DeleteFunc(slices.Clone(toolScopes), func(scope string) bool {
+			return !slices.Contains(openidConfig.ScopesSupported, scope)
+		})
+	}
+
+	messages := []dto.LtiToolMessage{
+		{
+			Type:          "LtiResourceLinkRequest",
+			TargetLinkUri: s.cfg.LtiConfig.LaunchUrl,
+			Label:         s.cfg.LtiConfig.ToolName,
+			Placements:    []string{"course_navigation"},
+		},
+		{
+			Type:          "LtiDeepLinkingRequest",
+			TargetLinkUri: s.cfg.LtiConfig.LaunchUrl,
+			Label:         s.cfg.LtiConfig.ToolName,
+			Placements:    []string{"assignment_selection", "editor_button", "link_selection"},
+		},
+	}
+	if supported := openidConfig.PlatformConfiguration.MessagesSupported; len(supported) > 0 {
+		messages = slices.DeleteFunc(messages, func(m dto.LtiToolMessage) bool {
+			return !slices.ContainsFunc(supported, func(p dto.LtiPlatformMessage) bool {
+				return p.Type == m.Type
+			})
+		})
+	}
+
+	return &dto.LtiToolRegistration{
+		ApplicationType:         "web",
+		ResponseTypes:           []string{"id_token"},
+		GrantTypes:              []string{"implicit", "client_credentials"},
+		InitiateLoginUri:        s.cfg.LtiConfig.LoginUrl,
+		RedirectUris:            []string{s.cfg.LtiConfig.LaunchUrl},
+		ClientName:              s.cfg.LtiConfig.ToolName,
+		JwksUri:                 s.cfg.LtiConfig.JwksUrl,
+		TokenEndpointAuthMethod: "private_key_jwt",
+		Scope:                   strings.Join(scopes, " "),
+		ToolConfiguration: dto.LtiToolConfiguration{
+			Domain:        launchUrl.Host,
+			TargetLinkUri: s.cfg.LtiConfig.LaunchUrl,
+			Claims:        toolClaims,
+			Messages:      messages,
+			PrivacyLevel:  "public",
+		},
+	}, nil
+}
@@ -0,0 +1,110 @@
+package lti
+
+import (
+	"context"
+	"go-lti/internal/domain/dto"
+	"go-lti/lib/config"
+	"net/url"
+	"testing"
+)
+
+func TestAllowedHost(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		host     string
+		want     bool
+	}{
+		{[]string{"*.instructure.com"}, "school.instructure.com", true},
+		{[]string{"*.instructure.com"}, "a.b.instructure.com", true},
+		{[]string{"*.instructure.com"}, "instructure.com", false},
+		{[]string{"*.instructure.com"}, "evilinstructure.com", false},
+		{[]string{"*.instructure.com"}, "instructure.com.evil.test", false},
+		{[]string{" *.Instructure.com "}, "school.instructure.com", true},
+		{[]string{"*instructure.com"}, "evilinstructure.com", false},
+		{[]string{"*instructure.com"}, "school.instructure.com", false},
+		{[]string{"*"}, "anything.test", false},
+		{[]string{"*."}, "anything.test", false},
+		{[]string{"*.*.test"}, "a.b.test", false},
+		{[]string{"canvas.school.edu"}, "canvas.school.edu", true},
+		{[]string{"canvas.school.edu"}, "other.canvas.school.edu", false},
+		{[]string{"canvas.school.edu", "*.instructure.com"}, "school.instructure.com", true},
+		{[]string{""}, "", false},
+		{nil, "school.instructure.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := allowedHost(tt.patterns, tt.host); got != tt.want {
+			t.Errorf("allowedHost(%q, %q) = %v, want %v", tt.patterns, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPlatformUrl(t *testing.T) {
+	s := &service{cfg: config.AppConfig{LtiConfig: config.CanvasLtiConfig{
+		RegistrationHosts: []string{"127.0.0.1", "10.0.0.1", "169.254.169.254", "8.8.8.8", "*.instructure.com"},
+	}}}
+
+	// IP literals resolve without a DNS lookup
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://8.8.8.8/api/lti/security/openid-configuration", false},
+		{"http://8.8.8.8/api/lti/security/openid-configuration", true},
+		{"https://127.0.0.1/api/lti/security/openid-configuration", true},
+		{"https://10.0.0.1/api/lti/security/openid-configuration", true},
+		{"https://169.254.169.254/latest/meta-data", true},
+		{"https://1.1.1.1/api/lti/security/openid-configuration", true},
+		{"https://evilinstructure.com/api/lti/security/openid-configuration", true},
+		{"/api/lti/security/openid-configuration", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		_, err := s.checkPlatformUrl(context.Background(), tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkPlatformUrl(%q) = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckPlatformConfiguration(t *testing.T) {
+	configUrl, _ := url.Parse("https://school.instructure.com/api/lti/security/openid-configuration")
+	valid := func() *dto.OpenidConfiguration {
+		return &dto.OpenidConfiguration{
+			Issuer:                "https://school.instructure.com",
+			AuthorizationEndpoint: "https://sso.canvaslms.com/api/lti/authorize_redirect",
+			TokenEndpoint:         "https://sso.canvaslms.com/login/oauth2/token",
+			JwksUri:               "https://sso.canvaslms.com/api/lti/security/jwks",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(openidConfig *dto.OpenidConfiguration)
+		wantErr bool
+	}{
+		{"valid", func(openidConfig *dto.OpenidConfiguration) {}, false},
+		{"issuer with path", func(openidConfig *dto.OpenidConfiguration) {
+			openidConfig.Issuer = "https://school.instructure.com/lti"
+		}, false},
+		{"issuer on another host", func(openidConfig *dto.OpenidConfiguration) { openidConfig.Issuer = "https://canvas.instructure.com" }, true},
+		{"http issuer", func(openidConfig *dto.OpenidConfiguration) { openidConfig.Issuer = "http://school.instructure.com" }, true},
+		{"http token endpoint", func(openidConfig *dto.OpenidConfiguration) {
+			openidConfig.TokenEndpoint = "http://sso.canvaslms.com/login/oauth2/token"
+		}, true},
+		{"relative jwks uri", func(openidConfig *dto.OpenidConfiguration) { openidConfig.JwksUri = "/api/lti/security/jwks" }, true},
+		{"missing authorization endpoint", func(openidConfig *dto.OpenidConfiguration) { openidConfig.AuthorizationEndpoint = "" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openidConfig := valid()
+			tt.mutate(openidConfig)
+
+			if err := checkPlatformConfiguration(configUrl, openidConfig); (err != nil) != tt.wantErr {
+				t.Errorf("checkPlatformConfiguration = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,25 +1,34 @@
 package lti
 
 import (
+	"errors"
 	"go-lti/internal/domain/dto"
 	"go-lti/internal/domain/interfaces"
+	"go-lti/internal/session"
+	"slices"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type httpHandler struct {
-	ltiService interfaces.LtiService
+	ltiService     interfaces.LtiService
+	noticeService  interfaces.NoticeService
+	sessionService interfaces.SessionService
 }
 
-func NewHttpHandler(r fiber.Router, ltiService interfaces.LtiService) {
+func NewHttpHandler(r fiber.Router, ltiService interfaces.LtiService, noticeService interfaces.NoticeService, sessionService interfaces.SessionService) {
 	handler := &httpHandler{
-		ltiService: ltiService,
+		ltiService:     ltiService,
+		noticeService:  noticeService,
+		sessionService: sessionService,
 	}
 
 	r.Post("/login", handler.ltiLogin)
 	r.Post("/launch", handler.ltiLaunch)
 	r.Get("/jwks", handler.jwks)
-	r.Get("/access_token", handler.requestAccessToken)
+	r.Get("/register", handler.dynamicRegistration)
+	r.Post("/deep_linking/response", session.Middleware(sessionService), handler.deepLinkingResponse)
+	r.Post("/notices", handler.receiveNotices)
 }
 
 func (h *httpHandler) jwks(c *fiber.Ctx) error {
@@ -37,12 +46,16 @@ func (h *httpHandler) ltiLogin(c *fiber.Ctx) error {
 		return err
 	}
 
-	authURL, err := h.ltiService.LtiLogin(c, request)
+	response, err := h.ltiService.LtiLogin(c, request)
 	if err != nil {
 		return err
 	}
 
-	return c.Redirect(authURL, fiber.StatusTemporaryRedirect)
+	if response.StorageTarget == "" {
+		return c.Redirect(response.AuthUrl, fiber.StatusTemporaryRedirect)
+	}
+
+	return renderHtml(c, loginStorageTemplate, response)
 }
 
 func (h *httpHandler) ltiLaunch(c *fiber.Ctx) error {
@@ -52,24 +65,82 @@ func (h *httpHandler) ltiLaunch(c *fiber.Ctx) error {
 	}
 
 	claims, err := h.ltiService.LtiLaunch(c, request)
+	var storageCheck *StorageCheckRequired
+	if errors.As(err, &storageCheck) {
+		return renderHtml(c, launchStorageTemplate, storageCheck.Page)
+	}
+	if err != nil {
+		return err
+	}
+	h.noticeService.SubscribeLaunch(claims)
+
+	var deepLinkingId string
+	if claims.MessageType == MessageTypeDeepLinkingRequest {
+		deepLinking, err := h.ltiService.StartDeepLinking(c, claims)
+		if err != nil {
+			return err
+		}
+		deepLinkingId = deepLinking.DeepLinkingId
+	}
+
+	_, token, err := h.sessionService.Create(c, claims, deepLinkingId)
 	if err != nil {
 		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(dto.ResponseDto{
-		Message: "LTI launch",
-		Data:    claims,
-	})
+	redirectUrl, err := h.sessionService.RedirectUrl(claims, token, deepLinkingId)
+	if err != nil {
+		return err
+	}
+
+	return c.Redirect(redirectUrl, fiber.StatusSeeOther)
 }
 
-func (h *httpHandler) requestAccessToken(c *fiber.Ctx) error {
-	accessToken, err := h.ltiService.RequestAccessToken(c)
+func (h *httpHandler) dynamicRegistration(c *fiber.Ctx) error {
+	request := new(dto.LtiDynamicRegistrationRequest)
+	if err := c.QueryParser(request); err != nil {
+		return err
+	}
+
+	if _, err := h.ltiService.DynamicRegistration(c, request); err != nil {
+		return err
+	}
+
+	c.Type("html")
+	return c.Status(fiber.StatusOK).SendString(registrationCompletePage)
+}
+
+func (h *httpHandler) deepLinkingResponse(c *fiber.Ctx) error {
+	request := new(dto.LtiDeepLinkingResponseRequest)
+	if err := c.BodyParser(request); err != nil {
+		return err
+	}
+	// only the deep linking launch of this session can be answered
+	request.DeepLinkingId = session.FromContext(c).DeepLinkingId
+
+	form, err := h.ltiService.DeepLinkingResponse(c, request)
+	if err != nil {
+		return err
+	}
+
+	return renderHtml(c, deepLinkingFormTemplate, form)
+}
+
+func (h *httpHandler) receiveNotices(c *fiber.Ctx) error {
+	request := new(dto.LtiNoticeRequest)
+	if err := c.BodyParser(request); err != nil {
+		return err
+	}
+
+	response, err := h.noticeService.ReceiveNotices(c, request)
 	if err != nil {
 		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(dto.ResponseDto{
-		Message: "LTI access token",
-		Data:    accessToken,
-	})
+	// 204 acknowledges the whole batch, otherwise every notice is reported with its own status
+	if !slices.ContainsFunc(response.Notices, func(result dto.LtiNoticeResult) bool { return result.Status != fiber.StatusOK }) {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	return c.Status(fiber.StatusMultiStatus).JSON(response)
 }
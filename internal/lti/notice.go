@@ -0,0 +1,292 @@
+package lti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/config"
+	"go-lti/lib/httpclient"
+	"go-lti/lib/jwks"
+	"go-lti/lib/store"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	NoticeTypeHelloWorld               = "LtiHelloWorldNotice"
+	NoticeTypeAssetProcessorSubmission = "LtiAssetProcessorSubmissionNotice"
+	NoticeTypeContextCopy              = "LtiContextCopyNotice"
+)
+
+type noticeService struct {
+	cfg           config.AppConfig
+	httpClient    httpclient.HttpClient
+	tokens        interfaces.LtiTokenManager
+	registrations interfaces.RegistrationStore
+	keySets       *jwks.Cache
+	// store remembers the jti of received notices until they expire, so a captured notice cannot be replayed
+	store store.Store
+
+	mu        sync.RWMutex
+	callbacks map[string][]interfaces.NoticeCallback
+	// subscribed are the platform notice services the handlers were registered with, by issuer, client and url
+	subscribed map[string]bool
+}
+
+const (
+	// noticeSubscribeTimeout bounds the background handler registration started by a launch
+	noticeSubscribeTimeout = 30 * time.Second
+
+	noticeJtiKeyPrefix = "lti:notice_jti:"
+)
+
+// errNoticeReplayed rejects a notice JWT that was already received
+var errNoticeReplayed = errors.New("notice was already received")
+
+// On : Register a callback for a notice type
+func (n *noticeService) On(noticeType string, callback interfaces.NoticeCallback) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.callbacks[noticeType] = append(n.callbacks[noticeType], callback)
+}
+
+// RegisterHandler : Register or replace the tool handler for a notice type with the platform
+func (n *noticeService) RegisterHandler(ctx context.Context, registration *dto.LtiRegistration, serviceUrl string, handler *dto.LtiNoticeHandler) (*dto.LtiNoticeHandler, error) {
+	if handler.NoticeType == "" {
+		return nil, errors.New("notice_type is required")
+	}
+
+	var registered dto.LtiNoticeHandler
+	_, err := callService(ctx, n.httpClient, n.tokens, registration, ScopeNoticeHandlers, http.MethodPut, serviceUrl, map[string]string{
+		fiber.HeaderContentType: fiber.MIMEApplicationJSON,
+		fiber.HeaderAccept:      fiber.MIMEApplicationJSON,
+	}, handler, &registered)
+	if err != nil {
+		return nil, err
+	}
+
+	return &registered, nil
+}
+
+// RegisterHandlers : Point every notice type that has a callback at the tool notice endpoint
+func (n *noticeService) RegisterHandlers(ctx context.Context, registration *dto.LtiRegistration, serviceUrl string) error {
+	if n.cfg.LtiConfig.NoticeUrl == "" {
+		return errors.New("notice url is not configured")
+	}
+
+	n.mu.RLock()
+	noticeTypes := make([]string, 0, len(n.callbacks))
+	for noticeType := range n.callbacks {
+		noticeTypes = append(noticeTypes, noticeType)
+	}
+	n.mu.RUnlock()
+
+	for _, noticeType := range noticeTypes {
+		_, err := n.RegisterHandler(ctx, registration, serviceUrl, &dto.LtiNoticeHandler{
+			NoticeType: noticeType,
+			Handler:    n.cfg.LtiConfig.NoticeUrl,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register %s handler: %w", noticeType, err)
+		}
+	}
+
+	return nil
+}
+
+// SubscribeLaunch : Register the notice handlers with the notice service of a launch, once per platform service.
+// Registration runs in the background so the launch is not delayed, a failure is retried on the next launch.
+func (n *noticeService) SubscribeLaunch(claims *dto.LtiJwtTokenClaims) {
+	serviceUrl := claims.PlatformNotificationService.PlatformNotificationURL
+	if serviceUrl == "" || n.cfg.LtiConfig.NoticeUrl == "" {
+		return
+	}
+
+	registration, err := n.registrations.FindDeployment(claims.Iss, claimsClientId(claims), claims.DeploymentID)
+	if err != nil {
+		return
+	}
+
+	key := tokenKey(registration, []string{serviceUrl})
+	n.mu.Lock()
+	if n.subscribed[key] || len(n.callbacks) == 0 {
+		n.mu.Unlock()
+		return
+	}
+	n.subscribed[key] = true
+	n.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), noticeSubscribeTimeout)
+		defer cancel()
+
+		if err := n.RegisterHandlers(ctx, registration, serviceUrl); err != nil {
+			log.Error().Err(err).Str("issuer", registration.Issuer).Msg("Failed to register notice handlers")
+
+			n.mu.Lock()
+			delete(n.subscribed, key)
+			n.mu.Unlock()
+		}
+	}()
+}
+
+// ListHandlers : Return the notice handlers the platform has registered for the tool
+func (n *noticeService) ListHandlers(ctx context.Context, registration *dto.LtiRegistration, serviceUrl string) (*dto.LtiNoticeHandlersResponse, error) {
+	var handlers dto.LtiNoticeHandlersResponse
+	_, err := callService(ctx, n.httpClient, n.tokens, registration, ScopeNoticeHandlers, http.MethodGet, serviceUrl, map[string]string{
+		fiber.HeaderAccept: fiber.MIMEApplicationJSON,
+	}, nil, &handlers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &handlers, nil
+}
+
+// ReceiveNotices : Verify a batch of platform notices and dispatch them to the registered callbacks.
+// Every notice is answered on its own, so an invalid notice does not hold back the valid ones of its batch.
+func (n *noticeService) ReceiveNotices(c *fiber.Ctx, request *dto.LtiNoticeRequest) (*dto.LtiNoticeResponse, error) {
+	response := &dto.LtiNoticeResponse{
+		Notices: make([]dto.LtiNoticeResult, 0, len(request.Notices)),
+	}
+	for _, item := range request.Notices {
+		response.Notices = append(response.Notices, n.receiveNotice(c.Context(), item.Jwt))
+	}
+
+	return response, nil
+}
+
+// receiveNotice : Private method to verify one notice and run its callbacks. A failing callback forgets the
+// notice jti so the platform can redeliver it.
+func (n *noticeService) receiveNotice(ctx context.Context, rawToken string) dto.LtiNoticeResult {
+	notice, err := n.verifyNotice(ctx, rawToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejected platform notice")
+		return dto.LtiNoticeResult{
+			Status: noticeErrorStatus(err),
+			Error:  err.Error(),
+		}
+	}
+	result := dto.LtiNoticeResult{
+		Id:     notice.Notice.Id,
+		Status: fiber.StatusOK,
+	}
+
+	n.mu.RLock()
+	callbacks := n.callbacks[notice.Notice.Type]
+	n.mu.RUnlock()
+
+	if len(callbacks) == 0 {
+		log.Warn().
+			Str("notice_type", notice.Notice.Type).
+			Str("notice_id", notice.Notice.Id).
+			Msg("No callback registered for notice")
+		return result
+	}
+
+	var errs []error
+	for _, callback := range callbacks {
+		if err := callback(ctx, notice); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		log.Error().
+			Err(errors.Join(errs...)).
+			Str("notice_type", notice.Notice.Type).
+			Str("notice_id", notice.Notice.Id).
+			Msg("Notice callback failed")
+
+		if err := n.store.Delete(ctx, noticeJtiKey(notice.Iss, notice.Jti)); err != nil {
+			log.Warn().Err(err).Msg("Failed to forget notice jti")
+		}
+
+		result.Status = fiber.StatusInternalServerError
+		result.Error = "notice callback failed"
+	}
+
+	return result
+}
+
+// noticeErrorStatus : Private method to choose the status of a notice that failed verification
+func noticeErrorStatus(err error) int {
+	var e *fiber.Error
+	var claimsErr *dto.ClaimsValidationError
+	switch {
+	case errors.Is(err, errNoticeReplayed):
+		return fiber.StatusConflict
+	case errors.As(err, &e):
+		return e.Code
+	case errors.As(err, &claimsErr):
+		return fiber.StatusUnauthorized
+	default:
+		// the platform keys or the store could not be reached, the notice can be redelivered later
+		return fiber.StatusInternalServerError
+	}
+}
+
+// verifyNotice : Private method to verify a notice JWT and check its deployment
+func (n *noticeService) verifyNotice(ctx context.Context, rawToken string) (*dto.LtiNotice, error) {
+	token, registration, err := verifyPlatformJWT(ctx, n.registrations, n.keySets, "notice", rawToken, noticeClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	var notice dto.LtiNotice
+	if err := decodeClaims(token, &notice); err != nil {
+		return nil, err
+	}
+
+	if _, err := n.registrations.FindDeployment(registration.Issuer, registration.ClientId, notice.DeploymentId); err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	if notice.Claims, err = token.AsMap(context.Background()); err != nil {
+		return nil, err
+	}
+	notice.Registration = registration
+
+	// the jti is kept as long as the token is accepted, securityClaims allows clockSkew past exp
+	ttl := time.Until(token.Expiration()) + clockSkew
+	err = n.store.Add(ctx, noticeJtiKey(notice.Iss, notice.Jti), notice.Notice.Id, ttl)
+	if errors.Is(err, store.ErrExists) {
+		return nil, errNoticeReplayed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &notice, nil
+}
+
+// noticeJtiKey : The store key of a received notice jti, jti values are only unique per issuer
+func noticeJtiKey(issuer string, jti string) string {
+	return noticeJtiKeyPrefix + issuer + "|" + jti
+}
+
+func NewNoticeService(
+	cfg config.AppConfig,
+	httpClient httpclient.HttpClient,
+	tokens interfaces.LtiTokenManager,
+	registrations interfaces.RegistrationStore,
+	keySets *jwks.Cache,
+	store store.Store,
+) interfaces.NoticeService {
+	return &noticeService{
+		cfg:           cfg,
+		httpClient:    httpClient,
+		tokens:        tokens,
+		registrations: registrations,
+		keySets:       keySets,
+		store:         store,
+		callbacks:     make(map[string][]interfaces.NoticeCallback),
+		subscribed:    make(map[string]bool),
+	}
+}
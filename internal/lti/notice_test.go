@@ -0,0 +1,293 @@
+package lti
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/lib/config"
+	"go-lti/lib/httpclient"
+	"go-lti/lib/jwks"
+	"go-lti/lib/store"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// fakeRegistrations is a RegistrationStore holding a single registration
+type fakeRegistrations struct {
+	registration *dto.LtiRegistration
+}
+
+func (f *fakeRegistrations) Find(issuer string, clientId string) (*dto.LtiRegistration, error) {
+	if issuer != f.registration.Issuer || clientId != f.registration.ClientId {
+		return nil, fmt.Errorf("unknown registration for issuer %s", issuer)
+	}
+	return f.registration, nil
+}
+
+func (f *fakeRegistrations) FindDeployment(issuer string, clientId string, deploymentId string) (*dto.LtiRegistration, error) {
+	registration, err := f.Find(issuer, clientId)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(registration.DeploymentIds, deploymentId) {
+		return nil, fmt.Errorf("unknown deployment_id %s", deploymentId)
+	}
+	return registration, nil
+}
+
+func (f *fakeRegistrations) List() []dto.LtiRegistration {
+	return []dto.LtiRegistration{*f.registration}
+}
+
+func (f *fakeRegistrations) Save(registration *dto.LtiRegistration) error {
+	return nil
+}
+
+// testPlatformKey : Return a signing key with kid k1 and a JWKS server publishing it
+func testPlatformKey(t *testing.T) (jwk.Key, *httptest.Server) {
+	t.Helper()
+
+	raw, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Set(jwk.KeyIDKey, "k1")
+	key.Set(jwk.AlgorithmKey, jwa.RS256)
+
+	public, err := jwk.PublicKeyOf(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := jwk.NewSet()
+	set.AddKey(public)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+
+	return key, srv
+}
+
+// signClaims : Sign claims like a platform would
+func signClaims(t *testing.T, key jwk.Key, claims map[string]any) string {
+	t.Helper()
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.New()
+	if err := json.Unmarshal(data, token); err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(signed)
+}
+
+func noticePayload(jti string, noticeType string, noticeId string) map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":             testRegistration.Issuer,
+		"aud":             testRegistration.ClientId,
+		"iat":             now.Unix(),
+		"exp":             now.Add(5 * time.Minute).Unix(),
+		"jti":             jti,
+		ClaimVersion:      "1.3.0",
+		ClaimDeploymentId: "1:abc",
+		ClaimNotice: map[string]any{
+			"id":        noticeId,
+			"timestamp": now.UTC().Format(time.RFC3339),
+			"type":      noticeType,
+		},
+	}
+}
+
+// newTestNoticeService : A notice service trusting key, whose hello world callback fails for the notice id "fail"
+func newTestNoticeService(t *testing.T) (*noticeService, jwk.Key, *[]string) {
+	t.Helper()
+
+	key, srv := testPlatformKey(t)
+	registration := *testRegistration
+	registration.JwksUrl = srv.URL
+	registration.DeploymentIds = []string{"1:abc"}
+
+	kv := store.NewMemoryStore(time.Hour)
+	t.Cleanup(func() { kv.Close() })
+
+	n := NewNoticeService(config.AppConfig{}, nil, nil, &fakeRegistrations{registration: &registration},
+		jwks.NewCache(httpclient.NewHttpClient(nil), nil), kv).(*noticeService)
+
+	var received []string
+	n.On(NoticeTypeHelloWorld, func(ctx context.Context, notice *dto.LtiNotice) error {
+		received = append(received, notice.Notice.Id)
+		if notice.Notice.Id == "fail" {
+			return errors.New("callback failed")
+		}
+		return nil
+	})
+
+	return n, key, &received
+}
+
+func TestReceiveNotice(t *testing.T) {
+	n, key, received := newTestNoticeService(t)
+
+	otherKey, _ := testPlatformKey(t)
+	valid := signClaims(t, key, noticePayload("jti-1", NoticeTypeHelloWorld, "n-1"))
+	failing := signClaims(t, key, noticePayload("jti-2", NoticeTypeHelloWorld, "fail"))
+
+	// the cases run in order against one service, replays depend on the earlier ones
+	tests := []struct {
+		name         string
+		token        func() string
+		wantStatus   int
+		wantReceived bool
+	}{
+		{"valid", func() string { return valid }, fiber.StatusOK, true},
+		{"replayed", func() string { return valid }, fiber.StatusConflict, false},
+		{"same notice in a new token", func() string {
+			return signClaims(t, key, noticePayload("jti-3", NoticeTypeHelloWorld, "n-1"))
+		}, fiber.StatusOK, true},
+		{"failing callback", func() string { return failing }, fiber.StatusInternalServerError, true},
+		{"redelivered after a failing callback", func() string { return failing }, fiber.StatusInternalServerError, true},
+		{"no callback for the type", func() string {
+			return signClaims(t, key, noticePayload("jti-4", NoticeTypeContextCopy, "n-2"))
+		}, fiber.StatusOK, false},
+		{"missing version", func() string {
+			claims := noticePayload("jti-5", NoticeTypeHelloWorld, "n-3")
+			delete(claims, ClaimVersion)
+			return signClaims(t, key, claims)
+		}, fiber.StatusUnauthorized, false},
+		{"other version", func() string {
+			claims := noticePayload("jti-6", NoticeTypeHelloWorld, "n-3")
+			claims[ClaimVersion] = "1.1"
+			return signClaims(t, key, claims)
+		}, fiber.StatusUnauthorized, false},
+		{"missing jti", func() string {
+			claims := noticePayload("", NoticeTypeHelloWorld, "n-3")
+			delete(claims, "jti")
+			return signClaims(t, key, claims)
+		}, fiber.StatusUnauthorized, false},
+		{"missing notice claim", func() string {
+			claims := noticePayload("jti-7", NoticeTypeHelloWorld, "n-3")
+			delete(claims, ClaimNotice)
+			return signClaims(t, key, claims)
+		}, fiber.StatusUnauthorized, false},
+		{"unknown deployment", func() string {
+			claims := noticePayload("jti-8", NoticeTypeHelloWorld, "n-3")
+			claims[ClaimDeploymentId] = "2:other"
+			return signClaims(t, key, claims)
+		}, fiber.StatusUnauthorized, false},
+		{"expired", func() string {
+			claims := noticePayload("jti-9", NoticeTypeHelloWorld, "n-3")
+			claims["exp"] = time.Now().Add(-10 * time.Minute).Unix()
+			return signClaims(t, key, claims)
+		}, fiber.StatusUnauthorized, false},
+		{"other audience", func() string {
+			claims := noticePayload("jti-10", NoticeTypeHelloWorld, "n-3")
+			claims["aud"] = "20000000000002"
+			return signClaims(t, key, claims)
+		}, fiber.StatusUnauthorized, false},
+		{"signed with another key", func() string {
+			return signClaims(t, otherKey, noticePayload("jti-11", NoticeTypeHelloWorld, "n-3"))
+		}, fiber.StatusUnauthorized, false},
+		{"not a jwt", func() string { return "not-a-jwt" }, fiber.StatusUnauthorized, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(*received)
+			result := n.receiveNotice(context.Background(), tt.token())
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %d (%s), want %d", result.Status, result.Error, tt.wantStatus)
+			}
+			if got := len(*received) > before; got != tt.wantReceived {
+				t.Errorf("callback called = %v, want %v", got, tt.wantReceived)
+			}
+		})
+	}
+}
+
+func TestReceiveNoticesBatch(t *testing.T) {
+	n, key, received := newTestNoticeService(t)
+
+	app := fiber.New()
+	NewHttpHandler(app.Group("/lti"), nil, n, nil)
+
+	send := func(tokens ...string) (int, *dto.LtiNoticeResponse) {
+		t.Helper()
+
+		request := dto.LtiNoticeRequest{}
+		for _, token := range tokens {
+			request.Notices = append(request.Notices, struct {
+				Jwt string `json:"jwt"`
+			}{Jwt: token})
+		}
+		body, _ := json.Marshal(request)
+
+		httpRequest := httptest.NewRequest(fiber.MethodPost, "/lti/notices", bytes.NewReader(body))
+		httpRequest.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		response, err := app.Test(httpRequest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var result dto.LtiNoticeResponse
+		json.NewDecoder(response.Body).Decode(&result)
+		return response.StatusCode, &result
+	}
+
+	status, _ := send(
+		signClaims(t, key, noticePayload("jti-1", NoticeTypeHelloWorld, "n-1")),
+		signClaims(t, key, noticePayload("jti-2", NoticeTypeHelloWorld, "n-2")),
+	)
+	if status != fiber.StatusNoContent {
+		t.Errorf("valid batch status = %d, want 204", status)
+	}
+
+	invalid := noticePayload("jti-3", NoticeTypeHelloWorld, "n-3")
+	invalid[ClaimVersion] = "1.1"
+	status, response := send(
+		signClaims(t, key, noticePayload("jti-4", NoticeTypeHelloWorld, "n-4")),
+		signClaims(t, key, invalid),
+		signClaims(t, key, noticePayload("jti-5", NoticeTypeHelloWorld, "n-5")),
+	)
+	if status != fiber.StatusMultiStatus {
+		t.Fatalf("mixed batch status = %d, want 207", status)
+	}
+
+	var statuses []int
+	for _, result := range response.Notices {
+		statuses = append(statuses, result.Status)
+	}
+	if !slices.Equal(statuses, []int{200, 401, 200}) {
+		t.Errorf("notice statuses = %v, want [200 401 200]", statuses)
+	}
+	if !slices.Equal(*received, []string{"n-1", "n-2", "n-4", "n-5"}) {
+		t.Errorf("received notices = %v, want the valid ones of both batches", *received)
+	}
+}
@@ -0,0 +1,94 @@
+package lti
+
+import (
+	"context"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/httpclient"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MediaTypeMembershipContainer = "application/vnd.ims.lti-nrps.v2.membershipcontainer+json"
+
+	MemberStatusActive   = "Active"
+	MemberStatusInactive = "Inactive"
+	MemberStatusDeleted  = "Deleted"
+)
+
+type nrpsClient struct {
+	httpClient httpclient.HttpClient
+	tokens     interfaces.LtiTokenManager
+}
+
+// GetMemberships : Return one page of the roster. Pass the page's NextUrl or DifferencesUrl as membershipsUrl with a nil query to continue
+func (n *nrpsClient) GetMemberships(ctx context.Context, registration *dto.LtiRegistration, membershipsUrl string, query *dto.LtiMembershipQuery) (*dto.LtiMembershipPage, error) {
+	requestUrl := membershipsUrl
+	if query != nil {
+		params := url.Values{}
+		setParam(params, "role", query.Role)
+		setParam(params, "rlid", query.ResourceLinkId)
+		if query.Limit > 0 {
+			params.Set("limit", strconv.Itoa(query.Limit))
+		}
+
+		var err error
+		if requestUrl, err = withQuery(membershipsUrl, params); err != nil {
+			return nil, err
+		}
+	}
+
+	var container dto.LtiMembershipContainer
+	res, err := callService(ctx, n.httpClient, n.tokens, registration, ScopeContextMembershipReadonly, http.MethodGet, requestUrl, map[string]string{
+		fiber.HeaderAccept: MediaTypeMembershipContainer,
+	}, nil, &container)
+	if err != nil {
+		return nil, err
+	}
+
+	links := httpclient.ParseLinks(res.Header)
+
+	return &dto.LtiMembershipPage{
+		LtiMembershipContainer: container,
+		NextUrl:                links["next"],
+		DifferencesUrl:         links["differences"],
+	}, nil
+}
+
+// GetAllMemberships : Follow every rel="next" page and return the whole roster with the differences URL of the last page
+func (n *nrpsClient) GetAllMemberships(ctx context.Context, registration *dto.LtiRegistration, membershipsUrl string, query *dto.LtiMembershipQuery) (*dto.LtiMembershipPage, error) {
+	page, err := n.GetMemberships(ctx, registration, membershipsUrl, query)
+	if err != nil {
+		return nil, err
+	}
+
+	roster := *page
+	for page.NextUrl != "" {
+		page, err = n.GetMemberships(ctx, registration, page.NextUrl, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		roster.Members = append(roster.Members, page.Members...)
+		if page.DifferencesUrl != "" {
+			roster.DifferencesUrl = page.DifferencesUrl
+		}
+	}
+	roster.NextUrl = ""
+
+	return &roster, nil
+}
+
+func NewNrpsClient(
+	httpClient httpclient.HttpClient,
+	tokens interfaces.LtiTokenManager,
+) interfaces.NrpsClient {
+	return &nrpsClient{
+		httpClient: httpClient,
+		tokens:     tokens,
+	}
+}
@@ -0,0 +1,53 @@
+package lti
+
+import (
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/internal/roles"
+	"go-lti/internal/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type nrpsHttpHandler struct {
+	nrpsClient    interfaces.NrpsClient
+	registrations interfaces.RegistrationStore
+}
+
+// NewNrpsHttpHandler serves the roster of the launch context to its instructors
+func NewNrpsHttpHandler(r fiber.Router, nrpsClient interfaces.NrpsClient, registrations interfaces.RegistrationStore, sessionService interfaces.SessionService) {
+	handler := &nrpsHttpHandler{
+		nrpsClient:    nrpsClient,
+		registrations: registrations,
+	}
+
+	r.Use(session.Middleware(sessionService), roles.Require(roles.Instructor, roles.TeachingAssistant))
+	r.Get("/members", handler.members)
+}
+
+func (h *nrpsHttpHandler) members(c *fiber.Ctx) error {
+	request := new(dto.LtiMembersRequest)
+	if err := c.QueryParser(request); err != nil {
+		return err
+	}
+
+	toolSession := session.FromContext(c)
+	if toolSession.MembershipsUrl == "" {
+		return fiber.NewError(fiber.StatusNotFound, "launch has no names and roles service")
+	}
+
+	registration, err := sessionRegistration(h.registrations, toolSession)
+	if err != nil {
+		return err
+	}
+
+	roster, err := h.nrpsClient.GetAllMemberships(c.Context(), registration, toolSession.MembershipsUrl, &dto.LtiMembershipQuery{
+		Role: request.Role,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the page URLs stay on the server, only the roster is returned
+	return c.JSON(roster.LtiMembershipContainer)
+}
@@ -0,0 +1,99 @@
+package lti
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/jwks"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// verifyPlatformJWT : Verify the signature of a JWT issued by a platform against the cached JWKS of its registration,
+// then check its claims against the IMS Security Framework and rules, reporting every failing claim at once
+func verifyPlatformJWT(ctx context.Context, registrations interfaces.RegistrationStore, keySets *jwks.Cache, tokenName string, rawToken string, rules ...claimsRule) (jwt.Token, *dto.LtiRegistration, error) {
+	registration, err := findTokenRegistration(registrations, rawToken)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	msg, err := jws.Parse([]byte(rawToken))
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+	var kid string
+	if signatures := msg.Signatures(); len(signatures) > 0 {
+		kid = signatures[0].ProtectedHeaders().KeyID()
+	}
+
+	// Get JWKS from the cache, refetching once if the key id is unknown
+	keySet, err := keySets.Lookup(ctx, registration.JwksUrl, kid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Parse and verify the signature, the claims are validated below
+	token, err := jwt.Parse([]byte(rawToken),
+		jwt.WithKeySet(keySet),
+		jwt.WithVerify(true),
+		jwt.WithValidate(false),
+	)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	claims, err := token.AsMap(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	validator := &claimsValidator{claims: claims}
+	for _, rule := range append([]claimsRule{securityClaims(registration, time.Now())}, rules...) {
+		rule(validator)
+	}
+	if err := validator.err(tokenName); err != nil {
+		return nil, nil, err
+	}
+
+	return token, registration, nil
+}
+
+// decodeClaims : Convert the claims of a verified token into the given struct, dates stay NumericDate seconds
+func decodeClaims(token jwt.Token, v any) error {
+	claimsBytes, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(claimsBytes, v)
+}
+
+// findTokenRegistration : Look up the registration of an unverified JWT by its iss and aud/azp claims
+func findTokenRegistration(registrations interfaces.RegistrationStore, rawToken string) (*dto.LtiRegistration, error) {
+	token, err := jwt.Parse([]byte(rawToken), jwt.WithVerify(false), jwt.WithValidate(false))
+	if err != nil {
+		return nil, err
+	}
+
+	if azp, ok := token.Get("azp"); ok {
+		if clientId, ok := azp.(string); ok && clientId != "" {
+			return registrations.Find(token.Issuer(), clientId)
+		}
+	}
+
+	audiences := token.Audience()
+	if len(audiences) == 1 {
+		return registrations.Find(token.Issuer(), audiences[0])
+	}
+	for _, aud := range audiences {
+		if registration, err := registrations.Find(token.Issuer(), aud); err == nil {
+			return registration, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown registration for issuer %s", token.Issuer())
+}
@@ -0,0 +1,110 @@
+package lti
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// deepLinkingFormTemplate posts the signed deep linking response back to the platform
+var deepLinkingFormTemplate = template.Must(template.New("deep_linking").Parse(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form method="POST" action="{{.ReturnUrl}}">
+<input type="hidden" name="JWT" value="{{.Jwt}}">
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>`))
+
+// registrationCompletePage tells the platform to close the registration window
+const registrationCompletePage = `<!DOCTYPE html>
+<html>
+<body>
+<p>Registration complete.</p>
+<script>
+(window.opener || window.parent).postMessage({ subject: "org.imsglobal.lti.close" }, "*");
+</script>
+</body>
+</html>`
+
+// loginStorageTemplate saves the signed state in the platform storage before continuing to the
+// platform authorization endpoint, so the launch can be verified when the state cookie is blocked
+var loginStorageTemplate = template.Must(template.New("login_storage").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<script>
+(function () {
+  var target = {{.StorageTarget}} === "_parent" ? window.parent : window.parent.frames[{{.StorageTarget}}];
+  var done = false;
+  function next() {
+    if (done) return;
+    done = true;
+    window.location.replace({{.AuthUrl}});
+  }
+  window.addEventListener("message", function (event) {
+    if (event.origin !== {{.PlatformOrigin}} || !event.data) return;
+    if (event.data.subject === "lti.put_data.response" && event.data.message_id === {{.MessageId}}) next();
+  });
+  target.postMessage({
+    subject: "lti.put_data",
+    message_id: {{.MessageId}},
+    key: {{.StorageKey}},
+    value: {{.StorageValue}}
+  }, {{.PlatformOrigin}});
+  setTimeout(next, 2000);
+})();
+</script>
+</body>
+</html>`))
+
+// launchStorageTemplate reads the state back from the platform storage and posts the launch again
+// from the tool origin with the value attached
+var launchStorageTemplate = template.Must(template.New("launch_storage").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<form method="POST" action="{{.LaunchUrl}}">
+<input type="hidden" name="id_token" value="{{.IdToken}}">
+<input type="hidden" name="state" value="{{.State}}">
+<input type="hidden" name="lti_storage_target" value="{{.StorageTarget}}">
+<input type="hidden" name="lti_storage_value" value="">
+</form>
+<p id="error" hidden>Unable to verify this launch, please enable cookies for this tool and try again.</p>
+<script>
+(function () {
+  var target = {{.StorageTarget}} === "_parent" ? window.parent : window.parent.frames[{{.StorageTarget}}];
+  var timer = setTimeout(function () {
+    document.getElementById("error").hidden = false;
+  }, 2000);
+  window.addEventListener("message", function (event) {
+    if (event.origin !== {{.PlatformOrigin}} || !event.data) return;
+    if (event.data.subject !== "lti.get_data.response" || event.data.message_id !== {{.MessageId}}) return;
+    clearTimeout(timer);
+    if (!event.data.value) {
+      document.getElementById("error").hidden = false;
+      return;
+    }
+    document.forms[0].elements["lti_storage_value"].value = event.data.value;
+    document.forms[0].submit();
+  });
+  target.postMessage({
+    subject: "lti.get_data",
+    message_id: {{.MessageId}},
+    key: {{.StorageKey}}
+  }, {{.PlatformOrigin}});
+})();
+</script>
+</body>
+</html>`))
+
+// renderHtml : Render a template as the HTML response
+func renderHtml(c *fiber.Ctx, tmpl *template.Template, data any) error {
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, data); err != nil {
+		return err
+	}
+
+	c.Type("html")
+	return c.Status(fiber.StatusOK).Send(page.Bytes())
+}
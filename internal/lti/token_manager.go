@@ -0,0 +1,173 @@
+package lti
+
+import (
+	"context"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/config"
+	"go-lti/lib/httpclient"
+	"go-lti/lib/keys"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// accessTokenExpirySkew is how long before expires_in a cached access token is replaced
+const accessTokenExpirySkew = 1 * time.Minute
+
+type tokenManager struct {
+	cfg        config.AppConfig
+	keys       *keys.Manager
+	httpClient httpclient.HttpClient
+
+	mu       sync.Mutex
+	tokens   map[string]*dto.LtiAccessTokenResponse
+	requests singleflight.Group
+}
+
+// Token : Return a cached access token for the scopes of a registration, requesting one from the platform when
+// there is none or it is about to expire. Concurrent requests for the same scopes share one upstream call.
+func (m *tokenManager) Token(ctx context.Context, registration *dto.LtiRegistration, scopes []string) (*dto.LtiAccessTokenResponse, error) {
+	scopes = normalizeScopes(scopes)
+	key := tokenKey(registration, scopes)
+
+	if token := m.cached(key); token != nil {
+		return token, nil
+	}
+
+	// the upstream call must outlive the caller that started it, the other callers are waiting for it
+	result := m.requests.DoChan(key, func() (any, error) {
+		if token := m.cached(key); token != nil {
+			return token, nil
+		}
+
+		token, err := requestAccessToken(context.WithoutCancel(ctx), m.cfg, m.keys, m.httpClient, registration, scopes)
+		if err != nil {
+			return nil, err
+		}
+		token.ExpiresAt = expiresAt(token.ExpiresIn)
+
+		if !token.ExpiresAt.IsZero() {
+			m.mu.Lock()
+			m.tokens[key] = token
+			m.mu.Unlock()
+		}
+
+		return token, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*dto.LtiAccessTokenResponse), nil
+	}
+}
+
+// Invalidate : Drop the cached access token for the scopes of a registration if it is still accessToken
+func (m *tokenManager) Invalidate(registration *dto.LtiRegistration, scopes []string, accessToken string) {
+	key := tokenKey(registration, normalizeScopes(scopes))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if token, ok := m.tokens[key]; ok && token.AccessToken == accessToken {
+		delete(m.tokens, key)
+	}
+}
+
+// cached : Private method to return the cached token of key while it is valid
+func (m *tokenManager) cached(key string) *dto.LtiAccessTokenResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.tokens[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(token.ExpiresAt) {
+		delete(m.tokens, key)
+		return nil
+	}
+
+	return token
+}
+
+// expiresAt : Time until which a token is reused, zero when the platform sent no expires_in
+func expiresAt(expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+
+	lifetime := time.Duration(expiresIn) * time.Second
+	// keep short lived tokens for half their lifetime rather than not at all
+	skew := min(accessTokenExpirySkew, lifetime/2)
+
+	return time.Now().Add(lifetime - skew)
+}
+
+// normalizeScopes : Sort and deduplicate scopes so the same set always shares a cache entry
+func normalizeScopes(scopes []string) []string {
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+
+	return slices.Compact(scopes)
+}
+
+func tokenKey(registration *dto.LtiRegistration, scopes []string) string {
+	return registration.Issuer + "|" + registration.ClientId + "|" + strings.Join(scopes, " ")
+}
+
+// scopedTokenSource is the bearer token source of LTI service requests, it drops the token the platform rejected
+type scopedTokenSource struct {
+	tokens       interfaces.LtiTokenManager
+	registration *dto.LtiRegistration
+	scopes       []string
+
+	mu   sync.Mutex
+	last string
+}
+
+// Token : Return the access token for the scopes of the registration
+func (s *scopedTokenSource) Token(ctx context.Context) (string, error) {
+	token, err := s.tokens.Token(ctx, s.registration, s.scopes)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.last = token.AccessToken
+	s.mu.Unlock()
+
+	return token.AccessToken, nil
+}
+
+// Refresh : Invalidate the rejected access token and request a new one
+func (s *scopedTokenSource) Refresh(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	last := s.last
+	s.mu.Unlock()
+
+	s.tokens.Invalidate(s.registration, s.scopes, last)
+
+	return s.Token(ctx)
+}
+
+func NewTokenManager(
+	cfg config.AppConfig,
+	keys *keys.Manager,
+	httpClient httpclient.HttpClient,
+) interfaces.LtiTokenManager {
+	return &tokenManager{
+		cfg:        cfg,
+		keys:       keys,
+		httpClient: httpClient,
+		tokens:     make(map[string]*dto.LtiAccessTokenResponse),
+	}
+}
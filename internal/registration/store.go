@@ -0,0 +1,240 @@
+package registration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/config"
+	"os"
+	"slices"
+	"sync"
+)
+
+type store struct {
+	mu            sync.RWMutex
+	path          string
+	registrations []dto.LtiRegistration
+	// persisted holds the registrations backed by the registrations file
+	persisted []dto.LtiRegistration
+}
+
+// Find : Return the registration for the given issuer and client_id.
+// When clientId is empty the issuer must have exactly one registration.
+func (s *store) Find(issuer string, clientId string) (*dto.LtiRegistration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []dto.LtiRegistration
+	for _, r := range s.registrations {
+		if r.Issuer != issuer {
+			continue
+		}
+		if clientId != "" && r.ClientId != clientId {
+			continue
+		}
+		matches = append(matches, r)
+	}
+
+	switch len(matches) {
+	case 0:
+		if clientId == "" {
+			return nil, fmt.Errorf("unknown issuer: %s", issuer)
+		}
+		return nil, fmt.Errorf("unknown registration for issuer %s and client_id %s", issuer, clientId)
+	case 1:
+		registration := matches[0]
+		return &registration, nil
+	default:
+		return nil, fmt.Errorf("issuer %s has multiple registrations, client_id is required", issuer)
+	}
+}
+
+// FindDeployment : Return the registration for the given issuer and client_id only if it contains the deployment
+func (s *store) FindDeployment(issuer string, clientId string, deploymentId string) (*dto.LtiRegistration, error) {
+	registration, err := s.Find(issuer, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(registration.DeploymentIds, deploymentId) {
+		return nil, fmt.Errorf("unknown deployment_id %s for client_id %s", deploymentId, registration.ClientId)
+	}
+
+	return registration, nil
+}
+
+// List : Return a copy of all known registrations
+func (s *store) List() []dto.LtiRegistration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.registrations)
+}
+
+// Save : Add a registration or merge the deployments of an existing one, and persist it to the registrations file
+func (s *store) Save(registration *dto.LtiRegistration) error {
+	if err := validate(*registration); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// registrations from the environment are kept in memory only
+	fromEnv := slices.ContainsFunc(s.registrations, sameRegistration(*registration)) &&
+		!slices.ContainsFunc(s.persisted, sameRegistration(*registration))
+
+	persisted := s.persisted
+	if !fromEnv {
+		persisted = upsert(s.persisted, *registration)
+		if err := s.write(persisted); err != nil {
+			return err
+		}
+	}
+
+	s.registrations = upsert(s.registrations, *registration)
+	s.persisted = persisted
+	return nil
+}
+
+// write : Atomically replace the registrations file
+func (s *store) write(registrations []dto.LtiRegistration) error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(registrations, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode registrations: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write registrations: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write registrations: %w", err)
+	}
+
+	return nil
+}
+
+// load : Read registrations from a JSON file containing an array of registrations
+func (s *store) load(path string) error {
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read registrations: %w", err)
+	}
+
+	var registrations []dto.LtiRegistration
+	if err := json.Unmarshal(data, &registrations); err != nil {
+		return fmt.Errorf("failed to parse registrations: %w", err)
+	}
+
+	for _, r := range registrations {
+		if err := s.add(r); err != nil {
+			return err
+		}
+		s.persisted = append(s.persisted, r)
+	}
+
+	return nil
+}
+
+// add : Validate and append a registration, rejecting duplicates
+func (s *store) add(r dto.LtiRegistration) error {
+	if err := validate(r); err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(s.registrations, sameRegistration(r)) {
+		return fmt.Errorf("duplicate registration for issuer %s and client_id %s", r.Issuer, r.ClientId)
+	}
+
+	s.registrations = append(s.registrations, r)
+	return nil
+}
+
+// validate : Check that a registration has the fields needed for launches
+func validate(r dto.LtiRegistration) error {
+	if r.Issuer == "" || r.ClientId == "" {
+		return errors.New("registration requires issuer and client_id")
+	}
+	if r.AuthLoginUrl == "" || r.AuthTokenUrl == "" || r.JwksUrl == "" {
+		return fmt.Errorf("registration %s/%s requires auth_login_url, auth_token_url and jwks_url", r.Issuer, r.ClientId)
+	}
+
+	return nil
+}
+
+// sameRegistration : Match registrations with the same issuer and client_id
+func sameRegistration(r dto.LtiRegistration) func(dto.LtiRegistration) bool {
+	return func(other dto.LtiRegistration) bool {
+		return other.Issuer == r.Issuer && other.ClientId == r.ClientId
+	}
+}
+
+// upsert : Return a copy of registrations with r merged into its existing entry or appended
+func upsert(registrations []dto.LtiRegistration, r dto.LtiRegistration) []dto.LtiRegistration {
+	result := slices.Clone(registrations)
+	if i := slices.IndexFunc(result, sameRegistration(r)); i >= 0 {
+		result[i] = mergeRegistration(result[i], r)
+		return result
+	}
+
+	return append(result, r)
+}
+
+// mergeRegistration : Update an existing registration with the endpoints and new deployments of another
+func mergeRegistration(existing dto.LtiRegistration, update dto.LtiRegistration) dto.LtiRegistration {
+	merged := update
+	merged.DeploymentIds = slices.Clone(existing.DeploymentIds)
+	for _, deploymentId := range update.DeploymentIds {
+		if !slices.Contains(merged.DeploymentIds, deploymentId) {
+			merged.DeploymentIds = append(merged.DeploymentIds, deploymentId)
+		}
+	}
+
+	return merged
+}
+
+// defaultRegistration : Build the registration described by the CANVAS_* environment variables
+func defaultRegistration(cfg config.AppConfig) dto.LtiRegistration {
+	canvasDomain := cfg.CanvasConfig.Domain
+
+	return dto.LtiRegistration{
+		Issuer:        cfg.LtiConfig.PlatformIssuer,
+		ClientId:      cfg.LtiConfig.ClientId,
+		DeploymentIds: cfg.LtiConfig.DeploymentIds,
+		AuthLoginUrl:  fmt.Sprintf("%s/api/lti/authorize_redirect", cfg.LtiConfig.PlatformIssuer),
+		AuthTokenUrl:  fmt.Sprintf("https://%s/login/oauth2/token", canvasDomain),
+		JwksUrl:       fmt.Sprintf("https://%s/api/lti/security/jwks", canvasDomain),
+	}
+}
+
+// NewStore creates a registration store seeded from the environment
+// configuration and, when configured, the registrations file.
+func NewStore(cfg config.AppConfig) (interfaces.RegistrationStore, error) {
+	s := &store{}
+
+	if cfg.LtiConfig.ClientId != "" {
+		if err := s.add(defaultRegistration(cfg)); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.LtiConfig.RegistrationsPath != "" {
+		if err := s.load(cfg.LtiConfig.RegistrationsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
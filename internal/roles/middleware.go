@@ -0,0 +1,30 @@
+package roles
+
+import (
+	"go-lti/internal/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Require allows requests whose tool session holds one of the allowed roles.
+// It runs after session.Middleware, sub-roles such as TeachingAssistant must be allowed explicitly.
+func Require(allowed ...Role) fiber.Handler {
+	return RequireFunc(func(uris []string) bool {
+		return Has(uris, allowed...)
+	})
+}
+
+// RequireFunc allows requests whose tool session roles pass check, e.g. RequireFunc(IsAdmin)
+func RequireFunc(check func(uris []string) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		toolSession := session.FromContext(c)
+		if toolSession == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "missing session")
+		}
+		if !check(toolSession.Roles) {
+			return fiber.NewError(fiber.StatusForbidden, "insufficient role")
+		}
+
+		return c.Next()
+	}
+}
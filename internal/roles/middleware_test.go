@@ -0,0 +1,100 @@
+package roles
+
+import (
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/session"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeSessions is a SessionService returning the same session for any token
+type fakeSessions struct {
+	session *dto.ToolSession
+}
+
+func (f *fakeSessions) Create(c *fiber.Ctx, claims *dto.LtiJwtTokenClaims, deepLinkingId string) (*dto.ToolSession, string, error) {
+	return f.session, "token", nil
+}
+
+func (f *fakeSessions) Get(c *fiber.Ctx, token string) (*dto.ToolSession, error) {
+	if token == "" {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "missing session")
+	}
+	return f.session, nil
+}
+
+func (f *fakeSessions) Delete(c *fiber.Ctx, token string) error {
+	return nil
+}
+
+func (f *fakeSessions) RedirectUrl(claims *dto.LtiJwtTokenClaims, token string, deepLinkingId string) (string, error) {
+	return "", nil
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []string
+		withToken  bool
+		withoutMw  bool
+		wantStatus int
+	}{
+		{"allowed role", []string{"Instructor"}, true, false, fiber.StatusOK},
+		{"allowed sub-role", []string{"http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#TeachingAssistant"}, true, false, fiber.StatusOK},
+		{"other role", []string{"Learner"}, true, false, fiber.StatusForbidden},
+		{"no roles", nil, true, false, fiber.StatusForbidden},
+		{"no session token", []string{"Instructor"}, false, false, fiber.StatusUnauthorized},
+		{"without session middleware", []string{"Instructor"}, true, true, fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &fakeSessions{session: &dto.ToolSession{Roles: tt.roles}}
+			ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+
+			app := fiber.New()
+			if tt.withoutMw {
+				app.Get("/", Require(Instructor, TeachingAssistant), ok)
+			} else {
+				app.Get("/", session.Middleware(sessions), Require(Instructor, TeachingAssistant), ok)
+			}
+
+			request := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.withToken {
+				request.Header.Set(fiber.HeaderAuthorization, "Bearer token")
+			}
+			response, err := app.Test(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequireFunc(t *testing.T) {
+	sessions := &fakeSessions{session: &dto.ToolSession{Roles: []string{"urn:lti:instrole:ims/lis/Administrator"}}}
+
+	app := fiber.New()
+	app.Get("/admin", session.Middleware(sessions), RequireFunc(IsAdmin), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/learners", session.Middleware(sessions), RequireFunc(IsLearner), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for path, want := range map[string]int{"/admin": fiber.StatusOK, "/learners": fiber.StatusForbidden} {
+		request := httptest.NewRequest(fiber.MethodGet, path+"?lti_session=token", nil)
+		response, err := app.Test(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.StatusCode != want {
+			t.Errorf("GET %s status = %d, want %d", path, response.StatusCode, want)
+		}
+	}
+}
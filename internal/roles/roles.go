@@ -0,0 +1,193 @@
+package roles
+
+import (
+	"slices"
+	"strings"
+)
+
+// Scope is the vocabulary a role belongs to
+type Scope string
+
+const (
+	ScopeSystem      Scope = "system"
+	ScopeInstitution Scope = "institution"
+	ScopeContext     Scope = "context"
+)
+
+const (
+	systemPrefix      = "http://purl.imsglobal.org/vocab/lis/v2/system/person#"
+	ltiSystemPrefix   = "http://purl.imsglobal.org/vocab/lti/system/person#"
+	institutionPrefix = "http://purl.imsglobal.org/vocab/lis/v2/institution/person#"
+	contextPrefix     = "http://purl.imsglobal.org/vocab/lis/v2/membership#"
+	subRolePrefix     = "http://purl.imsglobal.org/vocab/lis/v2/membership/"
+
+	// LTI 1.1 role URNs, still sent by some platforms
+	legacySystemPrefix      = "urn:lti:sysrole:ims/lis/"
+	legacyInstitutionPrefix = "urn:lti:instrole:ims/lis/"
+	legacyContextPrefix     = "urn:lti:role:ims/lis/"
+)
+
+// Role is an LTI role, SubRole narrows a context role e.g. Instructor#TeachingAssistant
+type Role struct {
+	Scope   Scope
+	Name    string
+	SubRole string
+}
+
+var (
+	SystemAdministrator      = Role{Scope: ScopeSystem, Name: "Administrator"}
+	SysAdmin                 = Role{Scope: ScopeSystem, Name: "SysAdmin"}
+	TestUser                 = Role{Scope: ScopeSystem, Name: "TestUser"}
+	InstitutionAdministrator = Role{Scope: ScopeInstitution, Name: "Administrator"}
+	InstitutionInstructor    = Role{Scope: ScopeInstitution, Name: "Instructor"}
+	InstitutionStudent       = Role{Scope: ScopeInstitution, Name: "Student"}
+	Administrator            = Role{Scope: ScopeContext, Name: "Administrator"}
+	ContentDeveloper         = Role{Scope: ScopeContext, Name: "ContentDeveloper"}
+	Instructor               = Role{Scope: ScopeContext, Name: "Instructor"}
+	TeachingAssistant        = Role{Scope: ScopeContext, Name: "Instructor", SubRole: "TeachingAssistant"}
+	Learner                  = Role{Scope: ScopeContext, Name: "Learner"}
+	Mentor                   = Role{Scope: ScopeContext, Name: "Mentor"}
+	Manager                  = Role{Scope: ScopeContext, Name: "Manager"}
+	Member                   = Role{Scope: ScopeContext, Name: "Member"}
+)
+
+// Parse reads a full role URI, an LTI 1.1 role URN or a short context role
+// name such as Instructor. It returns false for roles outside the LTI vocabularies.
+func Parse(uri string) (Role, bool) {
+	uri = strings.TrimSpace(uri)
+
+	switch {
+	case strings.HasPrefix(uri, systemPrefix):
+		return named(ScopeSystem, uri[len(systemPrefix):])
+	case strings.HasPrefix(uri, ltiSystemPrefix):
+		return named(ScopeSystem, uri[len(ltiSystemPrefix):])
+	case strings.HasPrefix(uri, institutionPrefix):
+		return named(ScopeInstitution, uri[len(institutionPrefix):])
+	case strings.HasPrefix(uri, contextPrefix):
+		return contextRole(uri[len(contextPrefix):], "")
+	case strings.HasPrefix(uri, subRolePrefix):
+		name, subRole, ok := strings.Cut(uri[len(subRolePrefix):], "#")
+		if !ok {
+			return Role{}, false
+		}
+		return contextRole(name, subRole)
+	case strings.HasPrefix(uri, legacySystemPrefix):
+		return named(ScopeSystem, uri[len(legacySystemPrefix):])
+	case strings.HasPrefix(uri, legacyInstitutionPrefix):
+		return named(ScopeInstitution, uri[len(legacyInstitutionPrefix):])
+	case strings.HasPrefix(uri, legacyContextPrefix):
+		name, subRole, _ := strings.Cut(uri[len(legacyContextPrefix):], "/")
+		return contextRole(name, subRole)
+	case strings.ContainsAny(uri, ":/"):
+		return Role{}, false
+	}
+
+	// the deprecated short form is only defined for context roles
+	name, subRole, _ := strings.Cut(uri, "#")
+	return contextRole(name, subRole)
+}
+
+// ParseAll parses every known role, skipping the ones Parse does not recognise
+func ParseAll(uris []string) []Role {
+	roles := make([]Role, 0, len(uris))
+	for _, uri := range uris {
+		if role, ok := Parse(uri); ok && !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// Normalize rewrites known roles to their full URI and drops duplicates,
+// roles outside the LTI vocabularies are kept as sent
+func Normalize(uris []string) []string {
+	normalized := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		if role, ok := Parse(uri); ok {
+			uri = role.String()
+		}
+		if !slices.Contains(normalized, uri) {
+			normalized = append(normalized, uri)
+		}
+	}
+
+	return normalized
+}
+
+// String returns the full URI of the role
+func (r Role) String() string {
+	switch r.Scope {
+	case ScopeSystem:
+		if r.Name == TestUser.Name {
+			return ltiSystemPrefix + r.Name
+		}
+		return systemPrefix + r.Name
+	case ScopeInstitution:
+		return institutionPrefix + r.Name
+	}
+
+	if r.SubRole != "" {
+		return subRolePrefix + r.Name + "#" + r.SubRole
+	}
+	return contextPrefix + r.Name
+}
+
+// Principal returns the role without its sub-role
+func (r Role) Principal() Role {
+	return Role{Scope: r.Scope, Name: r.Name}
+}
+
+// Has reports whether any of uris is exactly one of the wanted roles. A sub-role
+// does not grant its principal role, platforms send both when the user holds both.
+func Has(uris []string, wanted ...Role) bool {
+	return slices.ContainsFunc(ParseAll(uris), func(role Role) bool {
+		return slices.Contains(wanted, role)
+	})
+}
+
+// IsInstructor reports whether uris hold the context Instructor role
+func IsInstructor(uris []string) bool {
+	return Has(uris, Instructor)
+}
+
+// IsTeachingAssistant reports whether uris hold the Instructor#TeachingAssistant sub-role
+func IsTeachingAssistant(uris []string) bool {
+	return Has(uris, TeachingAssistant)
+}
+
+// IsLearner reports whether uris hold the context Learner role
+func IsLearner(uris []string) bool {
+	return Has(uris, Learner)
+}
+
+// IsMentor reports whether uris hold the context Mentor role
+func IsMentor(uris []string) bool {
+	return Has(uris, Mentor)
+}
+
+// IsAdmin reports whether uris hold an administrator role of the system, the institution or the context
+func IsAdmin(uris []string) bool {
+	return Has(uris, SystemAdministrator, SysAdmin, InstitutionAdministrator, Administrator)
+}
+
+// named : Build the role of a scope from the name following the vocabulary prefix
+func named(scope Scope, name string) (Role, bool) {
+	if name == "" {
+		return Role{}, false
+	}
+
+	return Role{Scope: scope, Name: name}, true
+}
+
+// contextRole : Build a context role, mapping the TeachingAssistant role of LTI 1.1 and the short form to its LTI 1.3 sub-role
+func contextRole(name string, subRole string) (Role, bool) {
+	if name == TeachingAssistant.SubRole && subRole == "" {
+		return TeachingAssistant, true
+	}
+	if name == "" {
+		return Role{}, false
+	}
+
+	return Role{Scope: ScopeContext, Name: name, SubRole: subRole}, true
+}
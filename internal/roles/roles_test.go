@@ -0,0 +1,165 @@
+package roles
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		uri    string
+		want   Role
+		wantOk bool
+	}{
+		{"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor", Instructor, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner", Learner, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#TeachingAssistant", TeachingAssistant, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/membership/Learner#Learner", Role{ScopeContext, "Learner", "Learner"}, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/membership#TeachingAssistant", TeachingAssistant, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/system/person#Administrator", SystemAdministrator, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/system/person#SysAdmin", SysAdmin, true},
+		{"http://purl.imsglobal.org/vocab/lti/system/person#TestUser", TestUser, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/institution/person#Administrator", InstitutionAdministrator, true},
+		{"http://purl.imsglobal.org/vocab/lis/v2/institution/person#Student", InstitutionStudent, true},
+		{"  http://purl.imsglobal.org/vocab/lis/v2/membership#Mentor ", Mentor, true},
+		{"Instructor", Instructor, true},
+		{"Learner", Learner, true},
+		{"TeachingAssistant", TeachingAssistant, true},
+		{"Instructor#TeachingAssistant", TeachingAssistant, true},
+		{"urn:lti:role:ims/lis/Instructor", Instructor, true},
+		{"urn:lti:role:ims/lis/TeachingAssistant", TeachingAssistant, true},
+		{"urn:lti:role:ims/lis/Instructor/TeachingAssistant", TeachingAssistant, true},
+		{"urn:lti:instrole:ims/lis/Administrator", InstitutionAdministrator, true},
+		{"urn:lti:sysrole:ims/lis/SysAdmin", SysAdmin, true},
+		{"", Role{}, false},
+		{"http://purl.imsglobal.org/vocab/lis/v2/membership#", Role{}, false},
+		{"http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor", Role{}, false},
+		{"http://purl.imsglobal.org/vocab/lis/v2/system/person#", Role{}, false},
+		{"https://canvas.instructure.com/lis/v2/membership#Observer", Role{}, false},
+		{"custom:role", Role{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Parse(tt.uri)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v, %v", tt.uri, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestRoleString(t *testing.T) {
+	tests := []struct {
+		role Role
+		want string
+	}{
+		{Instructor, "http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"},
+		{TeachingAssistant, "http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#TeachingAssistant"},
+		{SystemAdministrator, "http://purl.imsglobal.org/vocab/lis/v2/system/person#Administrator"},
+		{TestUser, "http://purl.imsglobal.org/vocab/lti/system/person#TestUser"},
+		{InstitutionAdministrator, "http://purl.imsglobal.org/vocab/lis/v2/institution/person#Administrator"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.role, got, tt.want)
+		}
+		if parsed, ok := Parse(tt.want); !ok || parsed != tt.role {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tt.want, parsed, ok, tt.role)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	teachingAssistant := "http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#TeachingAssistant"
+
+	tests := []struct {
+		name string
+		uris []string
+		want []string
+	}{
+		{"empty", nil, []string{}},
+		{"short form", []string{"Instructor"}, []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"}},
+		{"duplicates in different forms", []string{
+			"Learner",
+			"urn:lti:role:ims/lis/Learner",
+			"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner",
+		}, []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"}},
+		{"teaching assistant in every form", []string{
+			"TeachingAssistant",
+			"urn:lti:role:ims/lis/TeachingAssistant",
+			"http://purl.imsglobal.org/vocab/lis/v2/membership#TeachingAssistant",
+			teachingAssistant,
+		}, []string{teachingAssistant}},
+		{"unknown roles are kept", []string{"custom:role", "Learner", "custom:role"}, []string{
+			"custom:role",
+			"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.uris); !slices.Equal(got, tt.want) {
+				t.Errorf("Normalize(%v) = %v, want %v", tt.uris, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHas(t *testing.T) {
+	instructor := "http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"
+	teachingAssistant := "http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#TeachingAssistant"
+
+	tests := []struct {
+		name   string
+		uris   []string
+		wanted []Role
+		want   bool
+	}{
+		{"exact principal role", []string{instructor}, []Role{Instructor}, true},
+		{"short principal role", []string{"Instructor"}, []Role{Instructor}, true},
+		{"sub-role does not grant principal", []string{teachingAssistant}, []Role{Instructor}, false},
+		{"legacy teaching assistant does not grant instructor", []string{"urn:lti:role:ims/lis/TeachingAssistant"}, []Role{Instructor}, false},
+		{"sub-role allowed explicitly", []string{teachingAssistant}, []Role{Instructor, TeachingAssistant}, true},
+		{"principal does not grant sub-role", []string{instructor}, []Role{TeachingAssistant}, false},
+		{"principal and sub-role", []string{instructor, teachingAssistant}, []Role{Instructor}, true},
+		{"institution role is not a context role", []string{"http://purl.imsglobal.org/vocab/lis/v2/institution/person#Instructor"}, []Role{Instructor}, false},
+		{"no roles", nil, []Role{Learner}, false},
+		{"nothing wanted", []string{instructor}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Has(tt.uris, tt.wanted...); got != tt.want {
+				t.Errorf("Has(%v, %v) = %v, want %v", tt.uris, tt.wanted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelpers(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(uris []string) bool
+		uris  []string
+		want  bool
+	}{
+		{"instructor", IsInstructor, []string{"Instructor"}, true},
+		{"teaching assistant is not instructor", IsInstructor, []string{"http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#TeachingAssistant"}, false},
+		{"teaching assistant", IsTeachingAssistant, []string{"urn:lti:role:ims/lis/TeachingAssistant"}, true},
+		{"learner", IsLearner, []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"}, true},
+		{"instructor is not learner", IsLearner, []string{"Instructor"}, false},
+		{"mentor", IsMentor, []string{"Mentor"}, true},
+		{"system admin", IsAdmin, []string{"http://purl.imsglobal.org/vocab/lis/v2/system/person#SysAdmin"}, true},
+		{"institution admin", IsAdmin, []string{"urn:lti:instrole:ims/lis/Administrator"}, true},
+		{"context admin", IsAdmin, []string{"Administrator"}, true},
+		{"instructor is not admin", IsAdmin, []string{"Instructor"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check(tt.uris); got != tt.want {
+				t.Errorf("check(%v) = %v, want %v", tt.uris, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type httpHandler struct {
+	sessionService interfaces.SessionService
+}
+
+func NewHttpHandler(r fiber.Router, sessionService interfaces.SessionService) {
+	handler := &httpHandler{
+		sessionService: sessionService,
+	}
+
+	r.Use(Middleware(sessionService))
+	r.Get("/", handler.current)
+	r.Delete("/", handler.logout)
+}
+
+func (h *httpHandler) current(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(dto.ResponseDto{
+		Message: "Current session",
+		Data:    FromContext(c),
+	})
+}
+
+func (h *httpHandler) logout(c *fiber.Ctx) error {
+	if err := h.sessionService.Delete(c, FromContext(c).Id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const localsKey = "tool_session"
+
+// Middleware authenticates requests with the session token issued at launch,
+// sent as a bearer token or, for plain links, the lti_session query parameter.
+func Middleware(sessionService interfaces.SessionService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		session, err := sessionService.Get(c, token(c))
+		if err != nil {
+			return err
+		}
+
+		c.Locals(localsKey, session)
+		return c.Next()
+	}
+}
+
+// FromContext returns the session set by Middleware
+func FromContext(c *fiber.Ctx) *dto.ToolSession {
+	session, _ := c.Locals(localsKey).(*dto.ToolSession)
+	return session
+}
+
+// token : Read the session token from the Authorization header or query string
+func token(c *fiber.Ctx) string {
+	if auth := c.Get(fiber.HeaderAuthorization); auth != "" {
+		if scheme, value, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return c.Query("lti_session")
+}
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-lti/internal/domain/dto"
+	"go-lti/internal/domain/interfaces"
+	"go-lti/lib/config"
+	"go-lti/lib/store"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const sessionKeyPrefix = "session:"
+
+type service struct {
+	cfg   config.AppConfig
+	store store.Store
+}
+
+// Create : Mint a tool session for a validated launch and return it with its opaque token
+func (s *service) Create(c *fiber.Ctx, claims *dto.LtiJwtTokenClaims, deepLinkingId string) (*dto.ToolSession, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	clientId := claims.Azp
+	if clientId == "" && len(claims.Aud) > 0 {
+		clientId = claims.Aud[0]
+	}
+
+	session := &dto.ToolSession{
+		Issuer:           claims.Iss,
+		ClientId:         clientId,
+		DeploymentId:     claims.DeploymentID,
+		UserId:           claims.Sub,
+		Name:             claims.Name,
+		Email:            claims.Email,
+		Locale:           claims.Locale,
+		Roles:            claims.Roles,
+		ContextId:        claims.Context.ID,
+		ContextTitle:     claims.Context.Title,
+		ResourceLinkId:   claims.ResourceLink.ID,
+		MessageType:      claims.MessageType,
+		LineItemsUrl:     claims.Endpoint.LineItems,
+		AgsScopes:        claims.Endpoint.Scope,
+		MembershipsUrl:   claims.NamesRoleService.ContextMembershipsUrl,
+		NoticeHandlerUrl: claims.PlatformNotificationService.PlatformNotificationURL,
+		DeepLinkingId:    deepLinkingId,
+		ExpiresAt:        time.Now().Add(s.cfg.SessionConfig.TTL),
+	}
+	if deepLinkingId != "" {
+		settings := claims.DeepLinkingSettings
+		session.DeepLinkingSettings = &settings
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.store.Set(c.Context(), sessionKeyPrefix+token, string(data), s.cfg.SessionConfig.TTL); err != nil {
+		return nil, "", err
+	}
+	session.Id = token
+
+	return session, token, nil
+}
+
+// Get : Return the session of a token, or 401 when it is unknown or expired
+func (s *service) Get(c *fiber.Ctx, token string) (*dto.ToolSession, error) {
+	if token == "" {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "missing session")
+	}
+
+	data, err := s.store.Get(c.Context(), sessionKeyPrefix+token)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid or expired session")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session dto.ToolSession
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, err
+	}
+	session.Id = token
+
+	return &session, nil
+}
+
+// Delete : End a session
+func (s *service) Delete(c *fiber.Ctx, token string) error {
+	return s.store.Delete(c.Context(), sessionKeyPrefix+token)
+}
+
+// RedirectUrl : Build the frontend URL opened after the launch, carrying the session token.
+// target_link_uri is used unless it points back at the launch endpoint, and must stay on a tool origin.
+func (s *service) RedirectUrl(claims *dto.LtiJwtTokenClaims, token string, deepLinkingId string) (string, error) {
+	target := claims.TargetLinkURI
+	if target == "" || target == s.cfg.LtiConfig.LaunchUrl {
+		target = s.cfg.LtiConfig.AppUrl
+	}
+	if target == "" {
+		return "", fiber.NewError(fiber.StatusInternalServerError, "no target to redirect the launch to")
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid target_link_uri")
+	}
+	if !s.isToolHost(u.Host) {
+		return "", fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("target_link_uri host %s is not allowed", u.Host))
+	}
+
+	query := u.Query()
+	query.Set("lti_session", token)
+	if deepLinkingId != "" {
+		query.Set("deep_linking_id", deepLinkingId)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// isToolHost : Private method to check that a host belongs to the tool, preventing open redirects
+func (s *service) isToolHost(host string) bool {
+	for _, allowed := range []string{s.cfg.LtiConfig.LaunchUrl, s.cfg.LtiConfig.AppUrl} {
+		if u, err := url.Parse(allowed); err == nil && u.Host != "" && u.Host == host {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newToken : Generate an opaque session token
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func NewService(
+	cfg config.AppConfig,
+	store store.Store,
+) interfaces.SessionService {
+	return &service{
+		cfg:   cfg,
+		store: store,
+	}
+}
@@ -0,0 +1,120 @@
+package canvasapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Assignment is a Canvas assignment
+type Assignment struct {
+	Id                        int64                      `json:"id"`
+	Name                      string                     `json:"name"`
+	Description               string                     `json:"description"`
+	CourseId                  int64                      `json:"course_id"`
+	AssignmentGroupId         int64                      `json:"assignment_group_id"`
+	Position                  int                        `json:"position"`
+	PointsPossible            *float64                   `json:"points_possible"`
+	GradingType               string                     `json:"grading_type"`
+	SubmissionTypes           []string                   `json:"submission_types"`
+	AllowedExtensions         []string                   `json:"allowed_extensions,omitempty"`
+	DueAt                     *time.Time                 `json:"due_at"`
+	UnlockAt                  *time.Time                 `json:"unlock_at"`
+	LockAt                    *time.Time                 `json:"lock_at"`
+	Published                 bool                       `json:"published"`
+	OnlyVisibleToOverrides    bool                       `json:"only_visible_to_overrides"`
+	HtmlUrl                   string                     `json:"html_url"`
+	NeedsGradingCount         int                        `json:"needs_grading_count,omitempty"`
+	HasSubmittedSubmissions   bool                       `json:"has_submitted_submissions"`
+	ExternalToolTagAttributes *ExternalToolTagAttributes `json:"external_tool_tag_attributes,omitempty"`
+	Submission                *Submission                `json:"submission,omitempty"`
+	CreatedAt                 *time.Time                 `json:"created_at"`
+	UpdatedAt                 *time.Time                 `json:"updated_at"`
+}
+
+// ExternalToolTagAttributes links an assignment to an LTI tool
+type ExternalToolTagAttributes struct {
+	Url            string `json:"url"`
+	NewTab         bool   `json:"new_tab"`
+	ResourceLinkId string `json:"resource_link_id,omitempty"`
+}
+
+// AssignmentsOptions filters the assignments of a course
+type AssignmentsOptions struct {
+	ListOptions
+	SearchTerm    string   `url:"search_term,omitempty"`
+	Bucket        string   `url:"bucket,omitempty"`
+	OrderBy       string   `url:"order_by,omitempty"`
+	AssignmentIds []int64  `url:"assignment_ids[],omitempty"`
+	Include       []string `url:"include[],omitempty"`
+}
+
+// AssignmentOptions selects the optional data returned with an assignment
+type AssignmentOptions struct {
+	Include []string `url:"include[],omitempty"`
+}
+
+// AssignmentInput holds the fields sent when creating or updating an assignment, nil fields are left unchanged
+type AssignmentInput struct {
+	Name                      *string                    `json:"name,omitempty"`
+	Description               *string                    `json:"description,omitempty"`
+	PointsPossible            *float64                   `json:"points_possible,omitempty"`
+	GradingType               *string                    `json:"grading_type,omitempty"`
+	SubmissionTypes           []string                   `json:"submission_types,omitempty"`
+	AssignmentGroupId         *int64                     `json:"assignment_group_id,omitempty"`
+	Position                  *int                       `json:"position,omitempty"`
+	DueAt                     *time.Time                 `json:"due_at,omitempty"`
+	UnlockAt                  *time.Time                 `json:"unlock_at,omitempty"`
+	LockAt                    *time.Time                 `json:"lock_at,omitempty"`
+	Published                 *bool                      `json:"published,omitempty"`
+	ExternalToolTagAttributes *ExternalToolTagAttributes `json:"external_tool_tag_attributes,omitempty"`
+}
+
+type assignmentRequest struct {
+	Assignment *AssignmentInput `json:"assignment"`
+}
+
+// ListAssignments lists the assignments of a course
+func (c *Client) ListAssignments(courseId string, options *AssignmentsOptions) *Pager[Assignment] {
+	return newPager[Assignment](c, "/courses/"+escape(courseId)+"/assignments", options)
+}
+
+// GetAssignment returns an assignment of a course
+func (c *Client) GetAssignment(ctx context.Context, courseId string, assignmentId string, options *AssignmentOptions) (*Assignment, error) {
+	var assignment Assignment
+	if err := c.get(ctx, "/courses/"+escape(courseId)+"/assignments/"+escape(assignmentId), options, &assignment); err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+// CreateAssignment creates an assignment in a course
+func (c *Client) CreateAssignment(ctx context.Context, courseId string, input *AssignmentInput) (*Assignment, error) {
+	var assignment Assignment
+	if err := c.send(ctx, http.MethodPost, "/courses/"+escape(courseId)+"/assignments", &assignmentRequest{Assignment: input}, &assignment); err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+// UpdateAssignment updates an assignment of a course
+func (c *Client) UpdateAssignment(ctx context.Context, courseId string, assignmentId string, input *AssignmentInput) (*Assignment, error) {
+	var assignment Assignment
+	if err := c.send(ctx, http.MethodPut, "/courses/"+escape(courseId)+"/assignments/"+escape(assignmentId), &assignmentRequest{Assignment: input}, &assignment); err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+// DeleteAssignment deletes an assignment and returns it
+func (c *Client) DeleteAssignment(ctx context.Context, courseId string, assignmentId string) (*Assignment, error) {
+	var assignment Assignment
+	if err := c.send(ctx, http.MethodDelete, "/courses/"+escape(courseId)+"/assignments/"+escape(assignmentId), nil, &assignment); err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
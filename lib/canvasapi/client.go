@@ -0,0 +1,192 @@
+package canvasapi
+
+import (
+	"context"
+	"fmt"
+	"go-lti/lib/httpclient"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TokenSource returns the access token used for a request. It is called
+// before every request so expiring tokens can be refreshed.
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns a TokenSource that always returns token.
+func StaticToken(token string) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// ListOptions holds the pagination parameters shared by list endpoints
+type ListOptions struct {
+	// PerPage is the page size, Canvas caps it per endpoint (usually 100)
+	PerPage int `url:"per_page,omitempty"`
+}
+
+// Client is a typed client for the Canvas REST API of one Canvas instance.
+type Client struct {
+	httpClient httpclient.HttpClient
+	baseUrl    string
+	token      TokenSource
+	throttle   *Throttle
+}
+
+// get : Private method to fetch a single resource
+func (c *Client) get(ctx context.Context, path string, options any, result any) error {
+	_, err := c.do(ctx, http.MethodGet, c.url(path, options), nil, result)
+	return err
+}
+
+// send : Private method to create, update or delete a resource
+func (c *Client) send(ctx context.Context, method string, path string, body any, result any) error {
+	_, err := c.do(ctx, method, c.url(path, nil), body, result)
+	return err
+}
+
+// do : Private method to make an authenticated request to an absolute URL
+func (c *Client) do(ctx context.Context, method string, requestUrl string, body any, result any) (*httpclient.Response, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", token),
+		"Accept":        "application/json",
+		"Content-Type":  "application/json",
+	}
+	if c.throttle == nil {
+		return c.httpClient.Do(ctx, method, requestUrl, headers, body, result)
+	}
+
+	var res *httpclient.Response
+	err = c.throttle.do(ctx, token, func() (http.Header, bool, error) {
+		var err error
+		res, err = c.httpClient.Do(ctx, method, requestUrl, headers, body, result)
+		if res == nil {
+			return nil, false, err
+		}
+		return res.Header, isRateLimited(err), err
+	})
+
+	return res, err
+}
+
+// RateLimit returns the rate limit bucket of the client's access token, false
+// when the client is not throttled or Canvas has not reported it yet
+func (c *Client) RateLimit(ctx context.Context) (Level, bool, error) {
+	if c.throttle == nil {
+		return Level{}, false, nil
+	}
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return Level{}, false, err
+	}
+
+	level, ok := c.throttle.Level(token)
+	return level, ok, nil
+}
+
+// url : Private method to build the URL of an API path with the encoded options
+func (c *Client) url(path string, options any) string {
+	u := c.baseUrl + path
+	if query := EncodeQuery(options).Encode(); query != "" {
+		u += "?" + query
+	}
+
+	return u
+}
+
+// Pager walks a paginated Canvas list by following the rel="next" Link header.
+type Pager[T any] struct {
+	client *Client
+	next   string
+}
+
+func newPager[T any](c *Client, path string, options any) *Pager[T] {
+	return &Pager[T]{
+		client: c,
+		next:   c.url(path, options),
+	}
+}
+
+// HasNext reports whether another page can be fetched
+func (p *Pager[T]) HasNext() bool {
+	return p.next != ""
+}
+
+// NextPage fetches the next page. It returns nil once every page was read.
+func (p *Pager[T]) NextPage(ctx context.Context) ([]T, error) {
+	if p.next == "" {
+		return nil, nil
+	}
+
+	var page []T
+	res, err := p.client.do(ctx, http.MethodGet, p.next, nil, &page)
+	if err != nil {
+		return nil, err
+	}
+	p.next = httpclient.NextLink(res.Header)
+
+	return page, nil
+}
+
+// All iterates over every item of the remaining pages, fetching them as needed.
+// Iteration stops after the first error.
+func (p *Pager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for p.HasNext() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Collect reads every remaining page into a slice
+func (p *Pager[T]) Collect(ctx context.Context) ([]T, error) {
+	var items []T
+	for item, err := range p.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// escape : Escape an id used as a path segment, ids may be "self" or "sis_course_id:..."
+func escape(id string) string {
+	return url.PathEscape(id)
+}
+
+// NewClient creates a Canvas API client for the instance at domain, for
+// example "school.instructure.com", authenticated with token. Requests are
+// rate limited by throttle unless it is nil.
+func NewClient(httpClient httpclient.HttpClient, domain string, token TokenSource, throttle *Throttle) *Client {
+	baseUrl := domain
+	if !strings.HasPrefix(baseUrl, "http://") && !strings.HasPrefix(baseUrl, "https://") {
+		baseUrl = "https://" + baseUrl
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		baseUrl:    strings.TrimSuffix(baseUrl, "/") + "/api/v1",
+		token:      token,
+		throttle:   throttle,
+	}
+}
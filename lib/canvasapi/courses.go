@@ -0,0 +1,61 @@
+package canvasapi
+
+import (
+	"context"
+	"time"
+)
+
+// Course is a Canvas course
+type Course struct {
+	Id                          int64        `json:"id"`
+	Name                        string       `json:"name"`
+	CourseCode                  string       `json:"course_code"`
+	SisCourseId                 string       `json:"sis_course_id,omitempty"`
+	Uuid                        string       `json:"uuid,omitempty"`
+	WorkflowState               string       `json:"workflow_state"`
+	AccountId                   int64        `json:"account_id"`
+	EnrollmentTermId            int64        `json:"enrollment_term_id,omitempty"`
+	StartAt                     *time.Time   `json:"start_at"`
+	EndAt                       *time.Time   `json:"end_at"`
+	CreatedAt                   *time.Time   `json:"created_at,omitempty"`
+	DefaultView                 string       `json:"default_view,omitempty"`
+	TimeZone                    string       `json:"time_zone,omitempty"`
+	Locale                      string       `json:"locale,omitempty"`
+	IsPublic                    bool         `json:"is_public,omitempty"`
+	Enrollments                 []Enrollment `json:"enrollments,omitempty"`
+	TotalStudents               int          `json:"total_students,omitempty"`
+	Sections                    []Section    `json:"sections,omitempty"`
+	SyllabusBody                string       `json:"syllabus_body,omitempty"`
+	NeedsGradingCount           int          `json:"needs_grading_count,omitempty"`
+	ApplyAssignmentGroupWeights bool         `json:"apply_assignment_group_weights,omitempty"`
+}
+
+// CoursesOptions filters the courses of the current user
+type CoursesOptions struct {
+	ListOptions
+	EnrollmentType  string   `url:"enrollment_type,omitempty"`
+	EnrollmentRole  string   `url:"enrollment_role,omitempty"`
+	EnrollmentState string   `url:"enrollment_state,omitempty"`
+	State           []string `url:"state[],omitempty"`
+	Include         []string `url:"include[],omitempty"`
+}
+
+// CourseOptions selects the optional data returned with a course
+type CourseOptions struct {
+	Include []string `url:"include[],omitempty"`
+}
+
+// ListCourses lists the courses of the user of the access token
+func (c *Client) ListCourses(options *CoursesOptions) *Pager[Course] {
+	return newPager[Course](c, "/courses", options)
+}
+
+// GetCourse returns a course
+func (c *Client) GetCourse(ctx context.Context, courseId string, options *CourseOptions) (*Course, error) {
+	var course Course
+	if err := c.get(ctx, "/courses/"+escape(courseId), options, &course); err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
@@ -0,0 +1,58 @@
+package canvasapi
+
+import "time"
+
+// Enrollment is the membership of a user in a course section
+type Enrollment struct {
+	Id                             int64      `json:"id"`
+	CourseId                       int64      `json:"course_id"`
+	CourseSectionId                int64      `json:"course_section_id"`
+	UserId                         int64      `json:"user_id"`
+	Type                           string     `json:"type"`
+	Role                           string     `json:"role"`
+	RoleId                         int64      `json:"role_id"`
+	EnrollmentState                string     `json:"enrollment_state"`
+	LimitPrivilegesToCourseSection bool       `json:"limit_privileges_to_course_section"`
+	SisUserId                      string     `json:"sis_user_id,omitempty"`
+	SisSectionId                   string     `json:"sis_section_id,omitempty"`
+	HtmlUrl                        string     `json:"html_url,omitempty"`
+	Grades                         *Grades    `json:"grades,omitempty"`
+	User                           *User      `json:"user,omitempty"`
+	CreatedAt                      *time.Time `json:"created_at,omitempty"`
+	UpdatedAt                      *time.Time `json:"updated_at,omitempty"`
+	LastActivityAt                 *time.Time `json:"last_activity_at,omitempty"`
+}
+
+// Grades holds the course grades of a student enrollment
+type Grades struct {
+	HtmlUrl      string   `json:"html_url"`
+	CurrentScore *float64 `json:"current_score"`
+	CurrentGrade *string  `json:"current_grade"`
+	FinalScore   *float64 `json:"final_score"`
+	FinalGrade   *string  `json:"final_grade"`
+}
+
+// EnrollmentsOptions filters enrollments
+type EnrollmentsOptions struct {
+	ListOptions
+	Type    []string `url:"type[],omitempty"`
+	Role    []string `url:"role[],omitempty"`
+	State   []string `url:"state[],omitempty"`
+	UserId  string   `url:"user_id,omitempty"`
+	Include []string `url:"include[],omitempty"`
+}
+
+// ListCourseEnrollments lists the enrollments of a course
+func (c *Client) ListCourseEnrollments(courseId string, options *EnrollmentsOptions) *Pager[Enrollment] {
+	return newPager[Enrollment](c, "/courses/"+escape(courseId)+"/enrollments", options)
+}
+
+// ListSectionEnrollments lists the enrollments of a section
+func (c *Client) ListSectionEnrollments(sectionId string, options *EnrollmentsOptions) *Pager[Enrollment] {
+	return newPager[Enrollment](c, "/sections/"+escape(sectionId)+"/enrollments", options)
+}
+
+// ListUserEnrollments lists the enrollments of a user, pass "self" for the user of the access token
+func (c *Client) ListUserEnrollments(userId string, options *EnrollmentsOptions) *Pager[Enrollment] {
+	return newPager[Enrollment](c, "/users/"+escape(userId)+"/enrollments", options)
+}
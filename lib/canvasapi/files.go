@@ -0,0 +1,143 @@
+package canvasapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-lti/lib/httpclient"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// File is a file stored in Canvas
+type File struct {
+	Id          int64      `json:"id"`
+	Uuid        string     `json:"uuid"`
+	FolderId    int64      `json:"folder_id"`
+	DisplayName string     `json:"display_name"`
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content-type"`
+	Url         string     `json:"url"`
+	Size        int64      `json:"size"`
+	Locked      bool       `json:"locked"`
+	Hidden      bool       `json:"hidden"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+// FileUpload describes a file to upload
+type FileUpload struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// ParentFolderPath is created when it does not exist, ignored by submission uploads
+	ParentFolderPath string `json:"parent_folder_path,omitempty"`
+	ParentFolderId   string `json:"parent_folder_id,omitempty"`
+	// OnDuplicate is "overwrite" (default) or "rename"
+	OnDuplicate string `json:"on_duplicate,omitempty"`
+}
+
+// uploadTarget is the preflight response telling where to send the file
+type uploadTarget struct {
+	UploadUrl    string            `json:"upload_url"`
+	UploadParams map[string]string `json:"upload_params"`
+}
+
+// uploadResult is the response of the upload target, the file or where to confirm it
+type uploadResult struct {
+	File
+	Location string `json:"location"`
+}
+
+// UploadCourseFile uploads a file to the files of a course
+func (c *Client) UploadCourseFile(ctx context.Context, courseId string, upload *FileUpload, r io.Reader) (*File, error) {
+	return c.uploadFile(ctx, "/courses/"+escape(courseId)+"/files", upload, r)
+}
+
+// UploadUserFile uploads a file to the files of a user, "self" for the token owner
+func (c *Client) UploadUserFile(ctx context.Context, userId string, upload *FileUpload, r io.Reader) (*File, error) {
+	return c.uploadFile(ctx, "/users/"+escape(userId)+"/files", upload, r)
+}
+
+// UploadFolderFile uploads a file to a folder
+func (c *Client) UploadFolderFile(ctx context.Context, folderId string, upload *FileUpload, r io.Reader) (*File, error) {
+	return c.uploadFile(ctx, "/folders/"+escape(folderId)+"/files", upload, r)
+}
+
+// UploadSubmissionFile uploads a file to attach to a submission of a user, "self" for the token owner
+func (c *Client) UploadSubmissionFile(ctx context.Context, courseId string, assignmentId string, userId string, upload *FileUpload, r io.Reader) (*File, error) {
+	path := "/courses/" + escape(courseId) + "/assignments/" + escape(assignmentId) + "/submissions/" + escape(userId) + "/files"
+	return c.uploadFile(ctx, path, upload, r)
+}
+
+// uploadFile : Private method running the Canvas upload flow: a preflight request to path, the multipart
+// upload of r to the returned upload_url, then the confirmation of the upload when the target asks for it
+func (c *Client) uploadFile(ctx context.Context, path string, upload *FileUpload, r io.Reader) (*File, error) {
+	var target uploadTarget
+	if err := c.send(ctx, http.MethodPost, path, upload, &target); err != nil {
+		return nil, err
+	}
+	if target.UploadUrl == "" {
+		return nil, errors.New("canvas did not return an upload_url")
+	}
+
+	// the upload target may be another host, the access token is not sent to it
+	var result uploadResult
+	res, err := c.httpClient.Do(ctx, http.MethodPost, target.UploadUrl, map[string]string{
+		"Accept": "application/json",
+	}, &httpclient.Multipart{
+		Fields: target.UploadParams,
+		Files: []httpclient.File{{
+			Field:       "file",
+			Name:        upload.Name,
+			ContentType: upload.ContentType,
+			Reader:      r,
+		}},
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Id != 0 {
+		return &result.File, nil
+	}
+
+	location := result.Location
+	if location == "" {
+		location = res.Header.Get("Location")
+	}
+	if location == "" {
+		return nil, errors.New("canvas upload returned neither a file nor a location")
+	}
+
+	// the location comes from the upload host, it is only followed with the access token when it points back to Canvas
+	confirmUrl, err := c.canvasUrl(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var file File
+	if _, err := c.do(ctx, http.MethodGet, confirmUrl, nil, &file); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// canvasUrl : Private method to resolve a URL against the Canvas base URL, failing when it points to another host
+func (c *Client) canvasUrl(rawUrl string) (string, error) {
+	base, err := url.Parse(c.baseUrl)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(rawUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid canvas upload location: %w", err)
+	}
+	if u.Scheme != base.Scheme || u.Host != base.Host {
+		return "", fmt.Errorf("canvas upload location %s is not on %s", u.Redacted(), base.Host)
+	}
+
+	return u.String(), nil
+}
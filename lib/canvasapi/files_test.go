@@ -0,0 +1,124 @@
+package canvasapi
+
+import (
+	"context"
+	"encoding/json"
+	"go-lti/lib/httpclient"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCanvasUrl(t *testing.T) {
+	c := NewClient(nil, "school.instructure.com", StaticToken("t"), nil)
+
+	tests := []struct {
+		location string
+		want     string
+		wantErr  bool
+	}{
+		{"https://school.instructure.com/api/v1/files/1/create_success?uuid=u", "https://school.instructure.com/api/v1/files/1/create_success?uuid=u", false},
+		{"/api/v1/files/1", "https://school.instructure.com/api/v1/files/1", false},
+		{"https://uploads.example.com/files/1", "", true},
+		{"http://school.instructure.com/api/v1/files/1", "", true},
+		{"https://school.instructure.com:8443/api/v1/files/1", "", true},
+		{"https://school.instructure.com.evil.test/api/v1/files/1", "", true},
+		{"//evil.test/api/v1/files/1", "", true},
+		{"https://user@evil.test/api/v1/files/1", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := c.canvasUrl(tt.location)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("canvasUrl(%q) = %q, %v, want %q, error %v", tt.location, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	var canvasUrl, uploadUrl, foreignUrl string
+	var leaked []string
+
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"id":99}`))
+	}))
+	defer foreign.Close()
+	foreignUrl = foreign.URL
+
+	canvas := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer canvas-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/courses/1/files":
+			json.NewEncoder(w).Encode(map[string]any{
+				"upload_url":    uploadUrl,
+				"upload_params": map[string]string{"key": "k", "Policy": "p"},
+			})
+		case "/api/v1/files/7/create_success":
+			w.Write([]byte(`{"id":7,"display_name":"notes.txt"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer canvas.Close()
+	canvasUrl = canvas.URL
+
+	upload := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			leaked = append(leaked, r.Header.Get("Authorization"))
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil || r.FormValue("key") != "k" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+
+		switch r.URL.Query().Get("reply") {
+		case "location":
+			w.Header().Set("Location", canvasUrl+"/api/v1/files/7/create_success")
+			w.WriteHeader(http.StatusCreated)
+		case "foreign":
+			w.Header().Set("Location", foreignUrl+"/api/v1/files/7/create_success")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"id": 8, "display_name": "notes.txt", "size": len(content)})
+		}
+	}))
+	defer upload.Close()
+
+	tests := []struct {
+		name    string
+		reply   string
+		wantId  int64
+		wantErr bool
+	}{
+		{"file returned by the upload", "file", 8, false},
+		{"confirmed through a canvas location", "location", 7, false},
+		{"location on another host", "foreign", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaked = nil
+			uploadUrl = upload.URL + "/upload?reply=" + tt.reply
+			c := NewClient(httpclient.NewHttpClient(httpclient.DefaultConfig()), canvas.URL, StaticToken("canvas-token"), nil)
+
+			file, err := c.UploadCourseFile(context.Background(), "1", &FileUpload{Name: "notes.txt", ContentType: "text/plain"}, strings.NewReader("hello"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && file.Id != tt.wantId {
+				t.Errorf("file id = %d, want %d", file.Id, tt.wantId)
+			}
+			if len(leaked) != 0 {
+				t.Errorf("the canvas token was sent outside canvas: %v", leaked)
+			}
+		})
+	}
+}
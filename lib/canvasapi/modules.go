@@ -0,0 +1,76 @@
+package canvasapi
+
+import (
+	"context"
+	"time"
+)
+
+// Module is a module of a course
+type Module struct {
+	Id                        int64        `json:"id"`
+	Name                      string       `json:"name"`
+	Position                  int          `json:"position"`
+	WorkflowState             string       `json:"workflow_state"`
+	UnlockAt                  *time.Time   `json:"unlock_at"`
+	RequireSequentialProgress bool         `json:"require_sequential_progress"`
+	PrerequisiteModuleIds     []int64      `json:"prerequisite_module_ids"`
+	ItemsCount                int          `json:"items_count"`
+	ItemsUrl                  string       `json:"items_url"`
+	Items                     []ModuleItem `json:"items,omitempty"`
+	State                     string       `json:"state,omitempty"`
+	CompletedAt               *time.Time   `json:"completed_at,omitempty"`
+	Published                 *bool        `json:"published,omitempty"`
+}
+
+// ModuleItem is an item of a module
+type ModuleItem struct {
+	Id          int64  `json:"id"`
+	ModuleId    int64  `json:"module_id"`
+	Position    int    `json:"position"`
+	Title       string `json:"title"`
+	Indent      int    `json:"indent"`
+	Type        string `json:"type"`
+	ContentId   int64  `json:"content_id,omitempty"`
+	HtmlUrl     string `json:"html_url,omitempty"`
+	Url         string `json:"url,omitempty"`
+	PageUrl     string `json:"page_url,omitempty"`
+	ExternalUrl string `json:"external_url,omitempty"`
+	NewTab      bool   `json:"new_tab,omitempty"`
+	Published   *bool  `json:"published,omitempty"`
+}
+
+// ModulesOptions filters the modules of a course
+type ModulesOptions struct {
+	ListOptions
+	SearchTerm string   `url:"search_term,omitempty"`
+	StudentId  string   `url:"student_id,omitempty"`
+	Include    []string `url:"include[],omitempty"`
+}
+
+// ModuleItemsOptions filters the items of a module
+type ModuleItemsOptions struct {
+	ListOptions
+	SearchTerm string   `url:"search_term,omitempty"`
+	StudentId  string   `url:"student_id,omitempty"`
+	Include    []string `url:"include[],omitempty"`
+}
+
+// ListModules lists the modules of a course
+func (c *Client) ListModules(courseId string, options *ModulesOptions) *Pager[Module] {
+	return newPager[Module](c, "/courses/"+escape(courseId)+"/modules", options)
+}
+
+// GetModule returns a module of a course
+func (c *Client) GetModule(ctx context.Context, courseId string, moduleId string, options *ModulesOptions) (*Module, error) {
+	var module Module
+	if err := c.get(ctx, "/courses/"+escape(courseId)+"/modules/"+escape(moduleId), options, &module); err != nil {
+		return nil, err
+	}
+
+	return &module, nil
+}
+
+// ListModuleItems lists the items of a module
+func (c *Client) ListModuleItems(courseId string, moduleId string, options *ModuleItemsOptions) *Pager[ModuleItem] {
+	return newPager[ModuleItem](c, "/courses/"+escape(courseId)+"/modules/"+escape(moduleId)+"/items", options)
+}
@@ -0,0 +1,92 @@
+package canvasapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Page is a wiki page of a course
+type Page struct {
+	PageId        int64      `json:"page_id"`
+	Url           string     `json:"url"`
+	Title         string     `json:"title"`
+	Body          string     `json:"body,omitempty"`
+	Published     bool       `json:"published"`
+	FrontPage     bool       `json:"front_page"`
+	EditingRoles  string     `json:"editing_roles"`
+	LockedForUser bool       `json:"locked_for_user"`
+	HtmlUrl       string     `json:"html_url,omitempty"`
+	CreatedAt     *time.Time `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+	PublishAt     *time.Time `json:"publish_at,omitempty"`
+}
+
+// PagesOptions filters the pages of a course
+type PagesOptions struct {
+	ListOptions
+	Sort       string   `url:"sort,omitempty"`
+	Order      string   `url:"order,omitempty"`
+	SearchTerm string   `url:"search_term,omitempty"`
+	Published  *bool    `url:"published,omitempty"`
+	Include    []string `url:"include[],omitempty"`
+}
+
+// PageInput holds the fields sent when creating or updating a page, nil fields are left unchanged
+type PageInput struct {
+	Title        *string    `json:"title,omitempty"`
+	Body         *string    `json:"body,omitempty"`
+	EditingRoles *string    `json:"editing_roles,omitempty"`
+	Published    *bool      `json:"published,omitempty"`
+	FrontPage    *bool      `json:"front_page,omitempty"`
+	PublishAt    *time.Time `json:"publish_at,omitempty"`
+}
+
+type pageRequest struct {
+	WikiPage *PageInput `json:"wiki_page"`
+}
+
+// ListPages lists the pages of a course
+func (c *Client) ListPages(courseId string, options *PagesOptions) *Pager[Page] {
+	return newPager[Page](c, "/courses/"+escape(courseId)+"/pages", options)
+}
+
+// GetPage returns a page of a course by its url slug or "page_id:<id>"
+func (c *Client) GetPage(ctx context.Context, courseId string, pageUrl string) (*Page, error) {
+	var page Page
+	if err := c.get(ctx, "/courses/"+escape(courseId)+"/pages/"+escape(pageUrl), nil, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// CreatePage creates a page in a course
+func (c *Client) CreatePage(ctx context.Context, courseId string, input *PageInput) (*Page, error) {
+	var page Page
+	if err := c.send(ctx, http.MethodPost, "/courses/"+escape(courseId)+"/pages", &pageRequest{WikiPage: input}, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// UpdatePage updates a page of a course
+func (c *Client) UpdatePage(ctx context.Context, courseId string, pageUrl string, input *PageInput) (*Page, error) {
+	var page Page
+	if err := c.send(ctx, http.MethodPut, "/courses/"+escape(courseId)+"/pages/"+escape(pageUrl), &pageRequest{WikiPage: input}, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// DeletePage deletes a page and returns it
+func (c *Client) DeletePage(ctx context.Context, courseId string, pageUrl string) (*Page, error) {
+	var page Page
+	if err := c.send(ctx, http.MethodDelete, "/courses/"+escape(courseId)+"/pages/"+escape(pageUrl), nil, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
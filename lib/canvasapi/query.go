@@ -0,0 +1,99 @@
+package canvasapi
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeQuery converts an options struct into Canvas query parameters.
+//
+// Fields are named by their `url` tag, `omitempty` skips zero values and
+// embedded structs are flattened. Slices are sent as repeated parameters, so a
+// field tagged `url:"include[]"` becomes include[]=a&include[]=b as Canvas
+// expects for array parameters.
+func EncodeQuery(options any) url.Values {
+	values := url.Values{}
+	if options == nil {
+		return values
+	}
+
+	v := reflect.ValueOf(options)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return values
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		encodeStruct(values, v)
+	}
+
+	return values
+}
+
+// encodeStruct : Add the tagged fields of a struct to values
+func encodeStruct(values url.Values, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			encodeStruct(values, value)
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts == "omitempty" && value.IsZero() {
+			continue
+		}
+
+		for value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				break
+			}
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Pointer {
+			continue
+		}
+
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			for j := 0; j < value.Len(); j++ {
+				values.Add(name, formatValue(value.Index(j)))
+			}
+			continue
+		}
+		values.Add(name, formatValue(value))
+	}
+}
+
+// formatValue : Format a scalar the way Canvas parses it
+func formatValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,42 @@
+package canvasapi
+
+import (
+	"context"
+	"time"
+)
+
+// Section is a section of a course
+type Section struct {
+	Id               int64      `json:"id"`
+	Name             string     `json:"name"`
+	CourseId         int64      `json:"course_id"`
+	SisSectionId     string     `json:"sis_section_id,omitempty"`
+	IntegrationId    string     `json:"integration_id,omitempty"`
+	NonxlistCourseId *int64     `json:"nonxlist_course_id"`
+	StartAt          *time.Time `json:"start_at"`
+	EndAt            *time.Time `json:"end_at"`
+	TotalStudents    int        `json:"total_students,omitempty"`
+	Students         []User     `json:"students,omitempty"`
+}
+
+// SectionsOptions selects the optional data returned with sections
+type SectionsOptions struct {
+	ListOptions
+	SearchTerm string   `url:"search_term,omitempty"`
+	Include    []string `url:"include[],omitempty"`
+}
+
+// ListSections lists the sections of a course
+func (c *Client) ListSections(courseId string, options *SectionsOptions) *Pager[Section] {
+	return newPager[Section](c, "/courses/"+escape(courseId)+"/sections", options)
+}
+
+// GetSection returns a section of a course
+func (c *Client) GetSection(ctx context.Context, courseId string, sectionId string, options *SectionsOptions) (*Section, error) {
+	var section Section
+	if err := c.get(ctx, "/courses/"+escape(courseId)+"/sections/"+escape(sectionId), options, &section); err != nil {
+		return nil, err
+	}
+
+	return &section, nil
+}
@@ -0,0 +1,103 @@
+package canvasapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Submission is the submission of a user for an assignment
+type Submission struct {
+	Id                            int64               `json:"id"`
+	AssignmentId                  int64               `json:"assignment_id"`
+	UserId                        int64               `json:"user_id"`
+	GraderId                      *int64              `json:"grader_id"`
+	Attempt                       *int                `json:"attempt"`
+	Body                          *string             `json:"body"`
+	Url                           *string             `json:"url"`
+	Grade                         *string             `json:"grade"`
+	Score                         *float64            `json:"score"`
+	EnteredGrade                  *string             `json:"entered_grade"`
+	EnteredScore                  *float64            `json:"entered_score"`
+	GradeMatchesCurrentSubmission bool                `json:"grade_matches_current_submission"`
+	SubmissionType                *string             `json:"submission_type"`
+	WorkflowState                 string              `json:"workflow_state"`
+	Late                          bool                `json:"late"`
+	Missing                       bool                `json:"missing"`
+	Excused                       *bool               `json:"excused"`
+	PreviewUrl                    string              `json:"preview_url,omitempty"`
+	SubmittedAt                   *time.Time          `json:"submitted_at"`
+	GradedAt                      *time.Time          `json:"graded_at"`
+	PostedAt                      *time.Time          `json:"posted_at"`
+	SubmissionComments            []SubmissionComment `json:"submission_comments,omitempty"`
+	User                          *User               `json:"user,omitempty"`
+	Assignment                    *Assignment         `json:"assignment,omitempty"`
+}
+
+// SubmissionComment is a comment left on a submission
+type SubmissionComment struct {
+	Id         int64      `json:"id"`
+	AuthorId   int64      `json:"author_id"`
+	AuthorName string     `json:"author_name"`
+	Comment    string     `json:"comment"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// SubmissionsOptions selects the optional data returned with submissions
+type SubmissionsOptions struct {
+	ListOptions
+	Include []string `url:"include[],omitempty"`
+	Grouped bool     `url:"grouped,omitempty"`
+}
+
+// SubmissionOptions selects the optional data returned with a submission
+type SubmissionOptions struct {
+	Include []string `url:"include[],omitempty"`
+}
+
+// SubmissionGrade grades or comments on a submission, nil fields are left unchanged
+type SubmissionGrade struct {
+	// PostedGrade accepts points, a percentage ("80%"), a letter grade or pass/complete/fail/incomplete
+	PostedGrade *string `json:"posted_grade,omitempty"`
+	Excuse      *bool   `json:"excuse,omitempty"`
+	Comment     *string `json:"-"`
+}
+
+type gradeRequest struct {
+	Submission *SubmissionGrade `json:"submission,omitempty"`
+	Comment    *struct {
+		TextComment string `json:"text_comment"`
+	} `json:"comment,omitempty"`
+}
+
+// ListSubmissions lists the submissions of an assignment
+func (c *Client) ListSubmissions(courseId string, assignmentId string, options *SubmissionsOptions) *Pager[Submission] {
+	return newPager[Submission](c, "/courses/"+escape(courseId)+"/assignments/"+escape(assignmentId)+"/submissions", options)
+}
+
+// GetSubmission returns the submission of a user for an assignment
+func (c *Client) GetSubmission(ctx context.Context, courseId string, assignmentId string, userId string, options *SubmissionOptions) (*Submission, error) {
+	var submission Submission
+	if err := c.get(ctx, "/courses/"+escape(courseId)+"/assignments/"+escape(assignmentId)+"/submissions/"+escape(userId), options, &submission); err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// GradeSubmission grades the submission of a user and optionally adds a comment
+func (c *Client) GradeSubmission(ctx context.Context, courseId string, assignmentId string, userId string, grade *SubmissionGrade) (*Submission, error) {
+	request := &gradeRequest{Submission: grade}
+	if grade.Comment != nil {
+		request.Comment = &struct {
+			TextComment string `json:"text_comment"`
+		}{TextComment: *grade.Comment}
+	}
+
+	var submission Submission
+	if err := c.send(ctx, http.MethodPut, "/courses/"+escape(courseId)+"/assignments/"+escape(assignmentId)+"/submissions/"+escape(userId), request, &submission); err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
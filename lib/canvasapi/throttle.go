@@ -0,0 +1,328 @@
+package canvasapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-lti/lib/httpclient"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	headerRequestCost        = "X-Request-Cost"
+	headerRateLimitRemaining = "X-Rate-Limit-Remaining"
+
+	// sweepInterval is how often buckets of tokens that are no longer used are dropped
+	sweepInterval = 1 * time.Minute
+)
+
+// ThrottleConfig holds the configuration of the Canvas rate limiter.
+//
+// Canvas gives every access token a leaky bucket, each request costs a few
+// units that leak back over time and requests are rejected with 403 "Rate
+// Limit Exceeded" once it is empty.
+type ThrottleConfig struct {
+	// LowWatermark is the bucket level below which requests are delayed
+	LowWatermark float64
+	// RefillRate is the estimated number of units Canvas restores per second
+	RefillRate float64
+	// PreflightCost is reserved for every in-flight request, Canvas charges it up front
+	PreflightCost float64
+	// MaxConcurrent caps the in-flight requests per access token
+	MaxConcurrent int
+	// MaxDelay caps the time a request waits for the bucket to refill
+	MaxDelay time.Duration
+	// MaxRetries is the number of retries of a rate limited request
+	MaxRetries int
+	// RetryWaitTime is the initial backoff, doubled on every retry
+	RetryWaitTime time.Duration
+	// OnUpdate is called with the bucket level after every response, for metrics
+	OnUpdate func(key string, level Level)
+}
+
+// DefaultThrottleConfig returns the default configuration
+func DefaultThrottleConfig() *ThrottleConfig {
+	return &ThrottleConfig{
+		LowWatermark:  200,
+		RefillRate:    10,
+		PreflightCost: 50,
+		MaxConcurrent: 10,
+		MaxDelay:      30 * time.Second,
+		MaxRetries:    3,
+		RetryWaitTime: 2 * time.Second,
+	}
+}
+
+// Level is the rate limit bucket of an access token as last reported by Canvas
+type Level struct {
+	// Remaining is the bucket level reported by the last response
+	Remaining float64 `json:"remaining"`
+	// LastCost is the cost of the last request
+	LastCost float64 `json:"last_cost"`
+	// InFlight is the number of requests in progress
+	InFlight int `json:"in_flight"`
+	// UpdatedAt is the time of the last response
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Throttle tracks the Canvas rate limit bucket of each access token and
+// delays requests when it runs low. It is safe for concurrent use and meant
+// to be shared by every client of a process.
+type Throttle struct {
+	config *ThrottleConfig
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+}
+
+type bucket struct {
+	slots chan struct{}
+	// users is the number of requests holding the bucket, guarded by Throttle.mu
+	users int
+
+	mu       sync.Mutex
+	level    Level
+	known    bool
+	reserved float64
+}
+
+// Level returns the bucket of an access token, false when Canvas has not reported it yet
+func (t *Throttle) Level(token string) (Level, bool) {
+	t.mu.Lock()
+	b, ok := t.buckets[bucketKey(token)]
+	t.mu.Unlock()
+	if !ok {
+		return Level{}, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.level, b.known
+}
+
+// Levels returns the known bucket of every access token, keyed by a hash of the token
+func (t *Throttle) Levels() map[string]Level {
+	t.mu.Lock()
+	buckets := make(map[string]*bucket, len(t.buckets))
+	for key, b := range t.buckets {
+		buckets[key] = b
+	}
+	t.mu.Unlock()
+
+	levels := make(map[string]Level, len(buckets))
+	for key, b := range buckets {
+		b.mu.Lock()
+		if b.known {
+			levels[key] = b.level
+		}
+		b.mu.Unlock()
+	}
+
+	return levels
+}
+
+// do : Run a request within the rate limit of token, retrying it with backoff while Canvas reports the limit exceeded
+func (t *Throttle) do(ctx context.Context, token string, request func() (http.Header, bool, error)) error {
+	key := bucketKey(token)
+	b := t.bucket(key)
+	defer t.done(b)
+	backoff := t.config.RetryWaitTime
+
+	for attempt := 0; ; attempt++ {
+		if err := t.acquire(ctx, b); err != nil {
+			return err
+		}
+		header, limited, err := request()
+		t.release(key, b, header, limited)
+
+		if !limited || attempt >= t.config.MaxRetries {
+			return err
+		}
+
+		wait := backoff + rand.N(backoff/2+1)
+		log.Warn().
+			Int("retry", attempt+1).
+			Dur("wait_time", wait).
+			Msg("Canvas rate limit exceeded, retrying...")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+			backoff *= 2
+		}
+	}
+}
+
+// acquire : Take a request slot and wait until the bucket is expected to be above the low watermark
+func (t *Throttle) acquire(ctx context.Context, b *bucket) error {
+	select {
+	case b.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	b.mu.Lock()
+	delay := t.delay(b)
+	b.reserved += t.config.PreflightCost
+	b.level.InFlight++
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.reserved -= t.config.PreflightCost
+		b.level.InFlight--
+		b.mu.Unlock()
+		<-b.slots
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// release : Free the request slot and record the bucket level reported by Canvas
+func (t *Throttle) release(key string, b *bucket, header http.Header, limited bool) {
+	b.mu.Lock()
+	b.reserved -= t.config.PreflightCost
+	b.level.InFlight--
+
+	updated := false
+	if remaining, ok := parseFloatHeader(header, headerRateLimitRemaining); ok {
+		b.level.Remaining = remaining
+		updated = true
+	} else if limited {
+		b.level.Remaining = 0
+		updated = true
+	}
+	if cost, ok := parseFloatHeader(header, headerRequestCost); ok {
+		b.level.LastCost = cost
+	}
+	if updated {
+		b.known = true
+		b.level.UpdatedAt = time.Now()
+	}
+	level := b.level
+	b.mu.Unlock()
+
+	<-b.slots
+
+	if updated && t.config.OnUpdate != nil {
+		t.config.OnUpdate(key, level)
+	}
+}
+
+// delay : Time to wait for the bucket to refill above the low watermark, taking in-flight requests into account
+func (t *Throttle) delay(b *bucket) time.Duration {
+	if !b.known || t.config.RefillRate <= 0 {
+		return 0
+	}
+
+	estimated := b.level.Remaining + time.Since(b.level.UpdatedAt).Seconds()*t.config.RefillRate - b.reserved
+	if estimated >= t.config.LowWatermark {
+		return 0
+	}
+
+	delay := time.Duration((t.config.LowWatermark - estimated) / t.config.RefillRate * float64(time.Second))
+	return min(delay, t.config.MaxDelay)
+}
+
+// bucket : Return the bucket of a key, creating it when needed, and hold it until done is called
+func (t *Throttle) bucket(key string) *bucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now := time.Now(); now.Sub(t.sweptAt) >= sweepInterval {
+		t.sweep(now)
+		t.sweptAt = now
+	}
+
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &bucket{slots: make(chan struct{}, max(t.config.MaxConcurrent, 1))}
+		t.buckets[key] = b
+	}
+	b.users++
+
+	return b
+}
+
+// done : Release a bucket returned by bucket
+func (t *Throttle) done(b *bucket) {
+	t.mu.Lock()
+	b.users--
+	t.mu.Unlock()
+}
+
+// sweep : Drop the buckets nobody holds that refilled above the low watermark, a new bucket behaves the same.
+// Access tokens are refreshed hourly, so without it the map would grow for the life of the process. The caller holds mu.
+func (t *Throttle) sweep(now time.Time) {
+	for key, b := range t.buckets {
+		if b.users > 0 {
+			continue
+		}
+
+		b.mu.Lock()
+		idle := !b.known
+		if b.known && t.config.RefillRate > 0 {
+			refilled := b.level.Remaining + now.Sub(b.level.UpdatedAt).Seconds()*t.config.RefillRate
+			idle = refilled >= t.config.LowWatermark
+		}
+		b.mu.Unlock()
+
+		if idle {
+			delete(t.buckets, key)
+		}
+	}
+}
+
+// bucketKey : Hash an access token so tokens are not kept as map keys
+func bucketKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+func parseFloatHeader(header http.Header, name string) (float64, bool) {
+	value := strings.TrimSpace(header.Get(name))
+	if value == "" {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	return f, err == nil
+}
+
+// isRateLimited : Canvas answers an exceeded rate limit with 403 and a "Rate Limit Exceeded" body.
+// 429 responses are already retried by the http client.
+func isRateLimited(err error) bool {
+	var httpErr *httpclient.Error
+	return errors.As(err, &httpErr) &&
+		httpErr.StatusCode == http.StatusForbidden &&
+		strings.Contains(string(httpErr.Body), "Rate Limit Exceeded")
+}
+
+// NewThrottle creates a rate limiter for Canvas API requests
+func NewThrottle(config *ThrottleConfig) *Throttle {
+	if config == nil {
+		config = DefaultThrottleConfig()
+	}
+
+	return &Throttle{
+		config:  config,
+		buckets: make(map[string]*bucket),
+	}
+}
@@ -0,0 +1,52 @@
+package canvasapi
+
+import "context"
+
+// User is a Canvas user
+type User struct {
+	Id              int64        `json:"id"`
+	Name            string       `json:"name"`
+	SortableName    string       `json:"sortable_name"`
+	ShortName       string       `json:"short_name"`
+	SisUserId       string       `json:"sis_user_id,omitempty"`
+	LoginId         string       `json:"login_id,omitempty"`
+	AvatarUrl       string       `json:"avatar_url,omitempty"`
+	Email           string       `json:"email,omitempty"`
+	Locale          string       `json:"locale,omitempty"`
+	TimeZone        string       `json:"time_zone,omitempty"`
+	Bio             string       `json:"bio,omitempty"`
+	Enrollments     []Enrollment `json:"enrollments,omitempty"`
+	LastLogin       string       `json:"last_login,omitempty"`
+	EffectiveLocale string       `json:"effective_locale,omitempty"`
+}
+
+// CourseUsersOptions filters the users of a course
+type CourseUsersOptions struct {
+	ListOptions
+	SearchTerm      string   `url:"search_term,omitempty"`
+	Sort            string   `url:"sort,omitempty"`
+	EnrollmentType  []string `url:"enrollment_type[],omitempty"`
+	EnrollmentState []string `url:"enrollment_state[],omitempty"`
+	UserIds         []int64  `url:"user_ids[],omitempty"`
+	Include         []string `url:"include[],omitempty"`
+}
+
+// GetUser returns a user, pass "self" for the user of the access token
+func (c *Client) GetUser(ctx context.Context, userId string) (*User, error) {
+	var user User
+	if err := c.get(ctx, "/users/"+escape(userId), nil, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetSelf returns the user of the access token
+func (c *Client) GetSelf(ctx context.Context) (*User, error) {
+	return c.GetUser(ctx, "self")
+}
+
+// ListCourseUsers lists the users of a course
+func (c *Client) ListCourseUsers(courseId string, options *CourseUsersOptions) *Pager[User] {
+	return newPager[User](c, "/courses/"+escape(courseId)+"/users", options)
+}
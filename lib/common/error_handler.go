@@ -2,21 +2,72 @@ package common
 
 import (
 	"errors"
+	"fmt"
 	"go-lti/internal/domain/dto"
+	"go-lti/lib/httpclient"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
 )
 
 func ErrorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
+	message := err.Error()
+	var data any
 
 	var e *fiber.Error
-	if errors.As(err, &e) {
+	var httpErr *httpclient.Error
+	var claimsErr *dto.ClaimsValidationError
+	switch {
+	case errors.As(err, &e):
 		code = e.Code
+	case errors.As(err, &claimsErr):
+		code = fiber.StatusUnauthorized
+		message = "invalid " + claimsErr.Token
+		data = fiber.Map{
+			"errors": claimsErr.Errors,
+		}
+	case errors.As(err, &httpErr):
+		// the upstream payload can describe our credentials, it is logged and not returned
+		log.Warn().
+			Str("method", httpErr.Method).
+			Int("status", httpErr.StatusCode).
+			Str("error", httpErr.Message()).
+			Msg("Upstream request failed")
+
+		code = upstreamStatus(httpErr.StatusCode)
+		message = fmt.Sprintf("upstream request failed with status %d", httpErr.StatusCode)
+		data = fiber.Map{
+			"upstream_status": httpErr.StatusCode,
+		}
+		if retryAfter := httpErr.Header.Get(fiber.HeaderRetryAfter); retryAfter != "" && code == fiber.StatusTooManyRequests {
+			c.Set(fiber.HeaderRetryAfter, retryAfter)
+		}
 	}
 
 	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
 	return c.Status(code).JSON(dto.ResponseDto{
-		Message: err.Error(),
+		Message: message,
+		Data:    data,
 	})
 }
+
+// upstreamStatus : Translate the status of a failed upstream request into the status of our response.
+// Client errors the caller can act on are passed through, upstream failures become gateway errors.
+// A 401 or 403 rejects the credentials of the tool, not of our caller, and is a gateway error too.
+func upstreamStatus(status int) int {
+	switch status {
+	case fiber.StatusBadRequest,
+		fiber.StatusNotFound,
+		fiber.StatusConflict,
+		fiber.StatusUnprocessableEntity,
+		fiber.StatusTooManyRequests:
+		return status
+	case fiber.StatusGatewayTimeout:
+		return fiber.StatusGatewayTimeout
+	case fiber.StatusServiceUnavailable:
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusBadGateway
+	}
+}
@@ -1,16 +1,21 @@
 package config
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v11"
 	_ "github.com/joho/godotenv/autoload"
 )
 
 type AppConfig struct {
-	Port         string `env:"PORT" envDefault:"3000"`
-	CanvasConfig CanvasConfig
-	LtiConfig    CanvasLtiConfig
-	ApiKeyConfig CanvasApiKeyConfig
-	KeyConfig    KeyConfig
+	Port          string `env:"PORT" envDefault:"3000"`
+	CanvasConfig  CanvasConfig
+	LtiConfig     CanvasLtiConfig
+	ApiKeyConfig  CanvasApiKeyConfig
+	KeyConfig     KeyConfig
+	StoreConfig   StoreConfig
+	SessionConfig SessionConfig
+	AdminConfig   AdminConfig
 }
 
 type CanvasConfig struct {
@@ -18,21 +23,52 @@ type CanvasConfig struct {
 }
 
 type CanvasLtiConfig struct {
-	Issuer    string `env:"CANVAS_LTI_ISSUER"`
-	JwkKid    string `env:"CANVAS_LTI_JWK_KID"`
-	ClientId  string `env:"CANVAS_LTI_CLIENT_ID"`
-	LaunchUrl string `env:"CANVAS_LTI_LAUNCH_URL"`
+	Issuer            string   `env:"CANVAS_LTI_ISSUER"`
+	JwkKid            string   `env:"CANVAS_LTI_JWK_KID"`
+	JwkAlg            string   `env:"CANVAS_LTI_JWK_ALG"`
+	ClientId          string   `env:"CANVAS_LTI_CLIENT_ID"`
+	LaunchUrl         string   `env:"CANVAS_LTI_LAUNCH_URL"`
+	LoginUrl          string   `env:"CANVAS_LTI_LOGIN_URL"`
+	JwksUrl           string   `env:"CANVAS_LTI_JWKS_URL"`
+	NoticeUrl         string   `env:"CANVAS_LTI_NOTICE_URL"`
+	StateSecret       string   `env:"CANVAS_LTI_STATE_SECRET"`
+	AppUrl            string   `env:"CANVAS_LTI_APP_URL"`
+	ToolName          string   `env:"CANVAS_LTI_TOOL_NAME" envDefault:"Go LTI"`
+	PlatformIssuer    string   `env:"CANVAS_LTI_PLATFORM_ISSUER" envDefault:"https://canvas.instructure.com"`
+	DeploymentIds     []string `env:"CANVAS_LTI_DEPLOYMENT_IDS" envSeparator:","`
+	RegistrationsPath string   `env:"CANVAS_LTI_REGISTRATIONS_PATH" envDefault:"registrations.json"`
+	// RegistrationHosts are the platform hosts allowed to use dynamic registration, *.example.com matches subdomains
+	RegistrationHosts []string `env:"CANVAS_LTI_REGISTRATION_HOSTS" envSeparator:","`
 }
 
 type CanvasApiKeyConfig struct {
 	ClientId    string `env:"CANVAS_API_KEY_CLIENT_ID"`
 	Secret      string `env:"CANVAS_API_KEY_SECRET"`
 	RedirectUrl string `env:"CANVAS_API_KEY_REDIRECT_URL"`
+	TokenKey    string `env:"CANVAS_API_KEY_TOKEN_KEY"`
 }
 
 type KeyConfig struct {
-	PrivateKeyPath string `env:"PRIVATE_KEY_PATH"`
-	PublicKeyPath  string `env:"PUBLIC_KEY_PATH"`
+	PrivateKeyPath string        `env:"PRIVATE_KEY_PATH"`
+	PublicKeyPath  string        `env:"PUBLIC_KEY_PATH"`
+	RingPath       string        `env:"KEY_RING_PATH"`
+	RotationDelay  time.Duration `env:"KEY_ROTATION_DELAY" envDefault:"24h"`
+	RetireAfter    time.Duration `env:"KEY_RETIRE_AFTER" envDefault:"1h"`
+}
+
+type StoreConfig struct {
+	Driver   string `env:"STORE_DRIVER" envDefault:"memory"`
+	Path     string `env:"STORE_PATH" envDefault:"data/store.db"`
+	RedisUrl string `env:"STORE_REDIS_URL"`
+}
+
+type AdminConfig struct {
+	// Token is the bearer token of the admin endpoints, they are disabled when it is empty
+	Token string `env:"ADMIN_TOKEN"`
+}
+
+type SessionConfig struct {
+	TTL time.Duration `env:"SESSION_TTL" envDefault:"2h"`
 }
 
 func Setup() (AppConfig, error) {
@@ -0,0 +1,277 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	mimeJSON      = "application/json"
+	mimeForm      = "application/x-www-form-urlencoded"
+	mimeMultipart = "multipart/form-data"
+)
+
+// Multipart is a multipart/form-data request body. Files are streamed
+// from their readers while the request is sent.
+type Multipart struct {
+	// Fields are encoded like a form body, nested values use Canvas style a[b][] names
+	Fields any
+	Files  []File
+}
+
+// File is a file part of a Multipart body
+type File struct {
+	// Field is the form field name, "file" for Canvas uploads
+	Field       string
+	Name        string
+	ContentType string
+	Reader      io.Reader
+}
+
+// requestBody is an encoded body that can be opened once per attempt
+type requestBody struct {
+	// contentType replaces the Content-Type header when set, multipart adds its boundary
+	contentType string
+	open        func() (io.Reader, error)
+	// replayable is false when the body reads from a stream that cannot be rewound
+	replayable bool
+}
+
+// encodeBody : Encode body according to the request Content-Type.
+// An io.Reader is sent as is, a *Multipart as multipart/form-data, and other values as
+// an URL encoded form or JSON depending on the Content-Type header, JSON by default.
+func encodeBody(contentType string, body any) (*requestBody, error) {
+	switch b := body.(type) {
+	case nil:
+		return bytesBody(nil), nil
+	case *Multipart:
+		return multipartBody(b)
+	case []byte:
+		return bytesBody(b), nil
+	case io.Reader:
+		return readerBody(b), nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case mimeForm:
+		values, err := EncodeForm(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode form body: %w", err)
+		}
+		return bytesBody([]byte(values.Encode())), nil
+	case mimeMultipart:
+		return multipartBody(&Multipart{Fields: body})
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		return bytesBody(data), nil
+	}
+}
+
+func bytesBody(data []byte) *requestBody {
+	return &requestBody{
+		open: func() (io.Reader, error) {
+			return bytes.NewReader(data), nil
+		},
+		replayable: true,
+	}
+}
+
+// readerBody : A raw body, it can be replayed only when the reader can seek back to its start
+func readerBody(r io.Reader) *requestBody {
+	seeker, replayable := r.(io.Seeker)
+
+	return &requestBody{
+		open: func() (io.Reader, error) {
+			if replayable {
+				if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			// the transport closes request bodies, the caller owns the reader
+			if _, ok := r.(io.Closer); ok {
+				return io.NopCloser(r), nil
+			}
+			return r, nil
+		},
+		replayable: replayable,
+	}
+}
+
+// multipartBody : Stream the fields and files through a pipe so files are never held in memory
+func multipartBody(m *Multipart) (*requestBody, error) {
+	fields, err := EncodeForm(m.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode multipart fields: %w", err)
+	}
+
+	replayable := true
+	for _, file := range m.Files {
+		if _, ok := file.Reader.(io.Seeker); !ok {
+			replayable = false
+		}
+	}
+
+	// the boundary is fixed up front so the Content-Type header matches every attempt
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	return &requestBody{
+		contentType: mime.FormatMediaType(mimeMultipart, map[string]string{"boundary": boundary}),
+		open: func() (io.Reader, error) {
+			for _, file := range m.Files {
+				if seeker, ok := file.Reader.(io.Seeker); ok {
+					if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			pr, pw := io.Pipe()
+			go func() {
+				pw.CloseWithError(writeMultipart(pw, boundary, fields, m.Files))
+			}()
+			return pr, nil
+		},
+		replayable: replayable,
+	}, nil
+}
+
+func writeMultipart(w io.Writer, boundary string, fields map[string][]string, files []File) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	// fields go first, Canvas and S3 style upload targets expect them before the file
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range fields[name] {
+			if err := mw.WriteField(name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     file.Field,
+			"filename": file.Name,
+		}))
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, file.Reader); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// EncodeForm encodes v as form values with Canvas (Rails) style nesting:
+// nested objects become a[b]=..., lists of values a[]=... and lists of objects
+// a[0][b]=.... v may be url.Values, a map or a struct, which is read through
+// its json tags.
+func EncodeForm(v any) (url.Values, error) {
+	switch values := v.(type) {
+	case nil:
+		return url.Values{}, nil
+	case url.Values:
+		return values, nil
+	case map[string]string:
+		form := url.Values{}
+		for k, value := range values {
+			form.Set(k, value)
+		}
+		return form, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	object, ok := decoded.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("form body must be an object, got %T", v)
+	}
+
+	form := url.Values{}
+	flattenForm(form, "", object)
+	return form, nil
+}
+
+// flattenForm : Add a decoded JSON value to form under the Rails style name prefix
+func flattenForm(form url.Values, prefix string, value any) {
+	switch v := value.(type) {
+	case nil:
+		// Rails has no null, leaving the key out keeps the current value
+	case map[string]any:
+		for key, item := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "[" + key + "]"
+			}
+			flattenForm(form, name, item)
+		}
+	case []any:
+		for i, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				flattenForm(form, prefix+"["+strconv.Itoa(i)+"]", item)
+			default:
+				flattenForm(form, prefix+"[]", item)
+			}
+		}
+	case string:
+		form.Add(prefix, v)
+	case json.Number:
+		form.Add(prefix, v.String())
+	case bool:
+		form.Add(prefix, strconv.FormatBool(v))
+	default:
+		form.Add(prefix, fmt.Sprint(v))
+	}
+}
+
+// headerValue : Case insensitive lookup of a request header
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return ""
+}
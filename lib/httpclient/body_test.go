@@ -0,0 +1,174 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeForm(t *testing.T) {
+	type assignment struct {
+		Name           string   `json:"name"`
+		Points         float64  `json:"points_possible,omitempty"`
+		Published      bool     `json:"published"`
+		SubmissionType []string `json:"submission_types,omitempty"`
+		Description    *string  `json:"description"`
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		want  url.Values
+	}{
+		{"nil", nil, url.Values{}},
+		{"url values", url.Values{"a": {"1", "2"}}, url.Values{"a": {"1", "2"}}},
+		{"string map", map[string]string{"grant_type": "client_credentials"}, url.Values{"grant_type": {"client_credentials"}}},
+		{"nested object", map[string]any{"user": map[string]any{"name": "A", "email": "a@test"}}, url.Values{
+			"user[name]":  {"A"},
+			"user[email]": {"a@test"},
+		}},
+		{"list of values", map[string]any{"include": []string{"a", "b"}}, url.Values{"include[]": {"a", "b"}}},
+		{"list nested in object", map[string]any{"a": map[string]any{"b": []string{"x", "y"}}}, url.Values{"a[b][]": {"x", "y"}}},
+		{"list of objects", map[string]any{"items": []map[string]any{{"id": 1}, {"id": 2}}}, url.Values{
+			"items[0][id]": {"1"},
+			"items[1][id]": {"2"},
+		}},
+		{"list of lists", map[string]any{"m": [][]int{{1, 2}}}, url.Values{"m[0][]": {"1", "2"}}},
+		{"struct through json tags", map[string]any{"assignment": assignment{
+			Name:           "Quiz",
+			Points:         10.5,
+			SubmissionType: []string{"online_upload"},
+		}}, url.Values{
+			"assignment[name]":               {"Quiz"},
+			"assignment[points_possible]":    {"10.5"},
+			"assignment[published]":          {"false"},
+			"assignment[submission_types][]": {"online_upload"},
+		}},
+		{"large numbers are kept exactly", map[string]any{"id": int64(12345678901234567)}, url.Values{"id": {"12345678901234567"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeForm(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EncodeForm = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeFormNotObject(t *testing.T) {
+	if _, err := EncodeForm([]string{"a"}); err == nil {
+		t.Error("EncodeForm of a list should fail")
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		body            any
+		want            string
+		wantContentType string
+	}{
+		{"nil", mimeJSON, nil, "", ""},
+		{"json", mimeJSON, map[string]any{"a": 1}, `{"a":1}`, ""},
+		{"json by default", "", map[string]any{"a": 1}, `{"a":1}`, ""},
+		{"json suffix", "application/vnd.ims.lis.v1.score+json", map[string]any{"a": 1}, `{"a":1}`, ""},
+		{"form", mimeForm + "; charset=utf-8", map[string]any{"a": map[string]any{"b": []string{"x"}}}, "a%5Bb%5D%5B%5D=x", ""},
+		{"bytes", mimeForm, []byte("raw"), "raw", ""},
+		{"reader", mimeJSON, strings.NewReader("stream"), "stream", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := encodeBody(tt.contentType, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := body.open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := io.ReadAll(r)
+			if string(got) != tt.want || body.contentType != tt.wantContentType {
+				t.Errorf("body = %q, %q, want %q, %q", got, body.contentType, tt.want, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestMultipartBody(t *testing.T) {
+	body, err := encodeBody(mimeJSON, &Multipart{
+		Fields: map[string]any{"key": "k", "attachment": map[string]any{"tags": []string{"a", "b"}}},
+		Files: []File{
+			{Field: "file", Name: "notes.txt", ContentType: "text/plain", Reader: strings.NewReader("hello")},
+			{Field: "extra", Name: "data.bin", Reader: bytes.NewReader([]byte{1, 2})},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !body.replayable {
+		t.Error("a multipart body of seekable readers should be replayable")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(body.contentType)
+	if err != nil || mediaType != mimeMultipart || params["boundary"] == "" {
+		t.Fatalf("content type = %q", body.contentType)
+	}
+
+	// every attempt sends the whole body with the same boundary
+	for attempt := 1; attempt <= 2; attempt++ {
+		r, err := body.open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		type part struct{ name, filename, contentType, content string }
+		var parts []part
+		reader := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := io.ReadAll(p)
+			parts = append(parts, part{p.FormName(), p.FileName(), p.Header.Get("Content-Type"), string(content)})
+		}
+
+		want := []part{
+			{"attachment[tags][]", "", "", "a"},
+			{"attachment[tags][]", "", "", "b"},
+			{"key", "", "", "k"},
+			{"file", "notes.txt", "text/plain", "hello"},
+			{"extra", "data.bin", "application/octet-stream", "\x01\x02"},
+		}
+		if !reflect.DeepEqual(parts, want) {
+			t.Errorf("attempt %d parts = %v, want %v", attempt, parts, want)
+		}
+	}
+}
+
+func TestMultipartBodyStream(t *testing.T) {
+	body, err := encodeBody(mimeJSON, &Multipart{
+		Files: []File{{Field: "file", Name: "f", Reader: io.MultiReader(strings.NewReader("x"))}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.replayable {
+		t.Error("a multipart body reading from a stream cannot be replayed")
+	}
+}
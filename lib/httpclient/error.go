@@ -0,0 +1,168 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxErrorBody limits the part of the body included in the error message
+const maxErrorBody = 512
+
+// Error is returned by Call and Do when the server answers with a status of
+// 400 or above. Use errors.As to inspect it:
+//
+//	var httpErr *httpclient.Error
+//	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+//	    ...
+//	}
+type Error struct {
+	Method     string
+	Url        string
+	StatusCode int
+	Header     http.Header
+	// Body is the raw response body
+	Body []byte
+	// Attempts is the number of times the request was sent
+	Attempts int
+
+	// Messages holds the messages of a Canvas style `errors` payload
+	Messages []ErrorMessage
+	// Code and Description hold an OAuth2 `error` and `error_description`
+	Code        string
+	Description string
+}
+
+// ErrorMessage is one entry of a Canvas `errors` payload
+type ErrorMessage struct {
+	// Field is set for validation errors keyed by attribute
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+	Code    string `json:"error_code,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request failed with status %d after %d attempts: %s", e.StatusCode, e.Attempts, e.Message())
+}
+
+// Message returns the most useful description of the error: the decoded
+// payload when there is one, the start of the raw body otherwise.
+func (e *Error) Message() string {
+	var parts []string
+	if e.Code != "" {
+		if e.Description != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", e.Code, e.Description))
+		} else {
+			parts = append(parts, e.Code)
+		}
+	}
+	for _, m := range e.Messages {
+		if m.Field != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", m.Field, m.Message))
+		} else {
+			parts = append(parts, m.Message)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, "; ")
+	}
+
+	body := strings.TrimSpace(string(e.Body))
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody] + "..."
+	}
+	if body == "" {
+		return http.StatusText(e.StatusCode)
+	}
+
+	return body
+}
+
+// StatusCode returns the status of an *Error in err's chain, 0 when there is none
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+
+	return 0
+}
+
+// newError : Build an Error and decode the Canvas or OAuth2 payload of the body
+func newError(method string, url string, response *http.Response, body []byte, attempts int) *Error {
+	e := &Error{
+		Method:     method,
+		Url:        url,
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       body,
+		Attempts:   attempts,
+	}
+
+	var payload struct {
+		Errors           json.RawMessage `json:"errors"`
+		Message          string          `json:"message"`
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return e
+	}
+
+	var code string
+	if json.Unmarshal(payload.Error, &code) == nil {
+		e.Code = code
+	}
+	e.Description = payload.ErrorDescription
+	e.Messages = decodeErrorMessages(payload.Errors)
+	if len(e.Messages) == 0 && payload.Message != "" {
+		e.Messages = []ErrorMessage{{Message: payload.Message}}
+	}
+
+	return e
+}
+
+// decodeErrorMessages : Canvas returns errors as a list of messages or, for validation errors, as an object keyed by attribute
+func decodeErrorMessages(raw json.RawMessage) []ErrorMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var list []ErrorMessage
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+
+	var byField map[string]json.RawMessage
+	if json.Unmarshal(raw, &byField) != nil {
+		return nil
+	}
+
+	fields := make([]string, 0, len(byField))
+	for field := range byField {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var messages []ErrorMessage
+	for _, field := range fields {
+		var entries []ErrorMessage
+		if json.Unmarshal(byField[field], &entries) == nil {
+			for _, entry := range entries {
+				entry.Field = field
+				messages = append(messages, entry)
+			}
+			continue
+		}
+
+		var message string
+		if json.Unmarshal(byField[field], &message) == nil {
+			messages = append(messages, ErrorMessage{Field: field, Message: message})
+		}
+	}
+
+	return messages
+}
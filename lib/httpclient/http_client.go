@@ -1,12 +1,12 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -19,21 +19,49 @@ type Config struct {
 	RetryWaitTime    time.Duration
 	MaxRetryWaitTime time.Duration
 	DebugMode        bool
+	// RetryStatusCodes are the response statuses that are retried
+	RetryStatusCodes []int
+	// IdempotentMethods are retried on any retryable failure, other methods only on 429
+	IdempotentMethods []string
+	// Jitter spreads each backoff randomly by up to this fraction
+	Jitter float64
+	// Middlewares wrap every attempt, the first one is the outermost.
+	// DebugMode adds a Logging middleware in front of them.
+	Middlewares []Middleware
+	// Transport sends the requests, http.DefaultTransport when nil
+	Transport http.RoundTripper
+	// Redactor masks secrets in the DebugMode logs, NewRedactor(DefaultRedactConfig()) when nil
+	Redactor Redactor
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		Timeout:          30 * time.Second,
-		MaxRetries:       3,
-		RetryWaitTime:    1 * time.Second,
-		MaxRetryWaitTime: 10 * time.Second,
-		DebugMode:        false,
+		Timeout:           30 * time.Second,
+		MaxRetries:        3,
+		RetryWaitTime:     1 * time.Second,
+		MaxRetryWaitTime:  10 * time.Second,
+		DebugMode:         false,
+		RetryStatusCodes:  DefaultRetryStatusCodes,
+		IdempotentMethods: DefaultIdempotentMethods,
+		Jitter:            0.2,
 	}
 }
 
 type HttpClient interface {
 	Call(ctx context.Context, method string, url string, headers map[string]string, body interface{}, result interface{}) error
+	Do(ctx context.Context, method string, url string, headers map[string]string, body interface{}, result interface{}) (*Response, error)
+	// With returns a client sharing this client's configuration whose requests also go through middlewares,
+	// which wrap the existing chain
+	With(middlewares ...Middleware) HttpClient
+}
+
+// Response holds the status and headers of a completed request
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	// Attempts is the number of times the request was sent
+	Attempts int
 }
 
 type httpClient struct {
@@ -43,104 +71,150 @@ type httpClient struct {
 
 // Call executes an HTTP request with the specified method, URL, headers, and body.
 func (h *httpClient) Call(ctx context.Context, method string, url string, headers map[string]string, body interface{}, result interface{}) error {
+	_, err := h.Do(ctx, method, url, headers, body, result)
+	return err
+}
+
+// Do executes an HTTP request like Call and also returns the response status and headers.
+func (h *httpClient) Do(ctx context.Context, method string, url string, headers map[string]string, body interface{}, result interface{}) (*Response, error) {
 	switch method {
 	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
 		// supported method, no action needed
 	default:
-		return fmt.Errorf("unsupported HTTP method: %s", method)
+		return nil, fmt.Errorf("unsupported HTTP method: %s", method)
 	}
 
-	var reqData []byte
-	if body != nil {
-		var err error
-		reqData, err = json.Marshal(body)
-		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
-		}
+	contentType := headerValue(headers, "Content-Type")
+	if contentType == "" {
+		contentType = mimeJSON
 	}
 
-	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(reqData))
+	reqBody, err := encodeBody(contentType, body)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-
-	// Set default headers if not provided
-	if headers == nil {
-		headers = make(map[string]string)
-	}
-	if _, ok := headers["Content-Type"]; !ok {
-		headers["Content-Type"] = "application/json"
+	if reqBody.contentType != "" {
+		contentType = reqBody.contentType
 	}
 
+	// callers may share a header map between requests, so it is copied rather than modified
+	requestHeaders := make(map[string]string, len(headers)+1)
 	for k, v := range headers {
-		request.Header.Set(k, v)
+		if !strings.EqualFold(k, "Content-Type") {
+			requestHeaders[k] = v
+		}
 	}
-
-	// Log request
-	if h.config.DebugMode {
-		log.Debug().
-			Str("method", method).
-			Str("url", url).
-			Interface("headers", headers).
-			Interface("body", body).
-			Msg("Making HTTP request")
+	if body != nil {
+		requestHeaders["Content-Type"] = contentType
 	}
+	headers = requestHeaders
 
 	var response *http.Response
-	var retryCount int
-	backoff := h.config.RetryWaitTime
+	var resBody []byte
+	attempts := 0
 
-	for retryCount <= h.config.MaxRetries {
-		response, err = h.client.Do(request)
+	for {
+		attempts++
+		response, resBody, err = h.send(context.WithValue(ctx, attemptKey{}, attempts), method, url, headers, reqBody)
+
+		statusCode := 0
 		if err == nil {
+			statusCode = response.StatusCode
+		}
+		if attempts > h.config.MaxRetries || !reqBody.replayable || !h.shouldRetry(method, headers, statusCode, err) {
 			break
 		}
 
-		retryCount++
-		if retryCount > h.config.MaxRetries {
-			return fmt.Errorf("failed after %d retries: %w", h.config.MaxRetries, err)
+		wait := h.backoff(attempts)
+		if err == nil {
+			if after, ok := retryAfter(response.Header); ok {
+				// the server asked for a longer pause than we are willing to wait
+				if after > h.config.MaxRetryWaitTime {
+					break
+				}
+				wait = max(wait, after)
+			}
 		}
 
-		log.Warn().
-			Err(err).
-			Int("retry", retryCount).
-			Dur("wait_time", backoff).
-			Msg("Request failed, retrying...")
+		event := log.Warn().
+			Int("retry", attempts).
+			Dur("wait_time", wait)
+		if err != nil {
+			event = event.Err(err)
+		} else {
+			event = event.Int("status_code", statusCode)
+		}
+		event.Msg("Request failed, retrying...")
 
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(backoff):
-			backoff = min(backoff*2, h.config.MaxRetryWaitTime)
+			return nil, ctx.Err()
+		case <-time.After(wait):
 		}
 	}
-	defer response.Body.Close()
-
-	resBody, err := io.ReadAll(response.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("request failed after %d attempts: %w", attempts, err)
 	}
 
-	// Log response
-	if h.config.DebugMode {
-		log.Debug().
-			Int("status_code", response.StatusCode).
-			Str("response", string(resBody)).
-			Msg("Received HTTP response")
+	res := &Response{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Attempts:   attempts,
 	}
 
 	// Check for error status codes
 	if response.StatusCode >= 400 {
-		return fmt.Errorf("request failed with status %d: %s", response.StatusCode, string(resBody))
+		return res, newError(method, url, response, resBody, attempts)
 	}
 
 	if len(resBody) > 0 && result != nil {
 		if err := json.Unmarshal(resBody, result); err != nil {
-			return fmt.Errorf("failed to unmarshal response: %w", err)
+			return res, fmt.Errorf("failed to unmarshal response: %w", err)
 		}
 	}
 
-	return nil
+	return res, nil
+}
+
+// send : Private method to make one attempt. The body is reopened every time
+// because a body reader can only be consumed once.
+func (h *httpClient) send(ctx context.Context, method string, url string, headers map[string]string, reqBody *requestBody) (*http.Response, []byte, error) {
+	bodyReader, err := reqBody.open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open request body: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	resBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return response, resBody, nil
+}
+
+// With returns a client whose requests go through middlewares and then through this client's chain
+func (h *httpClient) With(middlewares ...Middleware) HttpClient {
+	return &httpClient{
+		client: &http.Client{
+			Timeout:   h.client.Timeout,
+			Transport: chain(h.client.Transport, middlewares),
+		},
+		config: h.config,
+	}
 }
 
 // NewHttpClient creates a new instance of HttpClient with the provided configuration.
@@ -148,10 +222,26 @@ func NewHttpClient(config *Config) HttpClient {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	if config.RetryStatusCodes == nil {
+		config.RetryStatusCodes = DefaultRetryStatusCodes
+	}
+	if config.IdempotentMethods == nil {
+		config.IdempotentMethods = DefaultIdempotentMethods
+	}
+
+	transport := config.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	middlewares := config.Middlewares
+	if config.DebugMode {
+		middlewares = append([]Middleware{Logging(LoggingOptions{Bodies: true, Redactor: config.Redactor})}, middlewares...)
+	}
 
 	return &httpClient{
 		client: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: chain(transport, middlewares),
 		},
 		config: config,
 	}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDoContentType(t *testing.T) {
+	var sent http.Header
+	client := NewHttpClient(&Config{
+		Transport: RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+			sent = request.Header
+			return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+		}),
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		body    any
+		want    string
+	}{
+		{"get without body", http.MethodGet, map[string]string{"Accept": "application/json"}, nil, ""},
+		{"get keeps no caller content type", http.MethodGet, map[string]string{"content-type": "application/json"}, nil, ""},
+		{"delete without body", http.MethodDelete, nil, nil, ""},
+		{"json by default", http.MethodPost, nil, map[string]any{"a": 1}, "application/json"},
+		{"caller content type", http.MethodPost, map[string]string{"content-type": "application/x-www-form-urlencoded"}, map[string]string{"a": "1"}, "application/x-www-form-urlencoded"},
+		{"multipart boundary", http.MethodPost, nil, &Multipart{Fields: map[string]string{"a": "1"}}, "multipart/form-data; boundary="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original map[string]string
+			if tt.headers != nil {
+				original = make(map[string]string, len(tt.headers))
+				for k, v := range tt.headers {
+					original[k] = v
+				}
+			}
+
+			if _, err := client.Do(context.Background(), tt.method, "https://canvas.test/api", tt.headers, tt.body, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			got := sent.Get("Content-Type")
+			if (tt.want == "" && got != "") || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.want)
+			}
+			if len(sent.Values("Content-Type")) > 1 {
+				t.Errorf("Content-Type sent %d times", len(sent.Values("Content-Type")))
+			}
+			if !reflect.DeepEqual(tt.headers, original) {
+				t.Errorf("headers = %v, want the caller's map unchanged %v", tt.headers, original)
+			}
+		})
+	}
+}
+
+func TestDoSharedHeaders(t *testing.T) {
+	var bodies []string
+	client := NewHttpClient(&Config{
+		Transport: RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+			body := ""
+			if request.Body != nil {
+				data, _ := io.ReadAll(request.Body)
+				body = string(data)
+			}
+			bodies = append(bodies, request.Header.Get("Content-Type")+" "+body)
+			return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+		}),
+	})
+
+	headers := map[string]string{"Authorization": "Bearer t"}
+	client.Do(context.Background(), http.MethodPost, "https://canvas.test/api", headers, &Multipart{Fields: map[string]string{"a": "1"}}, nil)
+	client.Do(context.Background(), http.MethodPost, "https://canvas.test/api", headers, map[string]any{"b": 2}, nil)
+
+	if len(headers) != 1 {
+		t.Errorf("headers = %v, want only the caller's Authorization", headers)
+	}
+	if want := `application/json {"b":2}`; len(bodies) != 2 || bodies[1] != want {
+		t.Errorf("second request = %q, want %q", bodies, want)
+	}
+}
@@ -0,0 +1,40 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ParseLinks parses RFC 8288 Link headers into a map of rel to URL.
+func ParseLinks(header http.Header) map[string]string {
+	links := make(map[string]string)
+
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = strings.Trim(target, "<>")
+
+			for _, param := range parts[1:] {
+				key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				// a rel may list several space separated relation types
+				for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+					links[strings.ToLower(rel)] = target
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+// NextLink returns the URL of the rel="next" page, or an empty string on the last page.
+func NextLink(header http.Header) string {
+	return ParseLinks(header)["next"]
+}
@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Redactor masks secrets before requests and responses are logged
+type Redactor interface {
+	Url(rawUrl string) string
+	Header(header http.Header) http.Header
+	Body(contentType string, body []byte) []byte
+}
+
+// LoggingOptions configures the Logging middleware
+type LoggingOptions struct {
+	// Bodies also logs request and response bodies
+	Bodies bool
+	// Redactor masks secrets, NewRedactor(DefaultRedactConfig()) when nil
+	Redactor Redactor
+}
+
+// Logging logs every attempt of a request and its response at debug level
+func Logging(options LoggingOptions) Middleware {
+	redactor := options.Redactor
+	if redactor == nil {
+		redactor = NewRedactor(DefaultRedactConfig())
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+
+			event := log.Debug().
+				Str("method", request.Method).
+				Str("url", redactor.Url(request.URL.String())).
+				Int("attempt", Attempt(request.Context())).
+				Interface("headers", redactor.Header(request.Header))
+			if options.Bodies {
+				if body := peekRequestBody(request); len(body) > 0 {
+					event = event.Str("body", string(redactor.Body(request.Header.Get("Content-Type"), body)))
+				}
+			}
+			event.Msg("Making HTTP request")
+
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				log.Debug().
+					Err(err).
+					Str("method", request.Method).
+					Str("url", redactor.Url(request.URL.String())).
+					Dur("duration", time.Since(start)).
+					Msg("HTTP request failed")
+				return nil, err
+			}
+
+			event = log.Debug().
+				Int("status_code", response.StatusCode).
+				Str("url", redactor.Url(request.URL.String())).
+				Dur("duration", time.Since(start)).
+				Interface("headers", redactor.Header(response.Header))
+			if options.Bodies {
+				var body []byte
+				body, response.Body, err = readBody(response.Body)
+				if err != nil {
+					return nil, err
+				}
+				event = event.Str("response", string(redactor.Body(response.Header.Get("Content-Type"), body)))
+			}
+			event.Msg("Received HTTP response")
+
+			return response, nil
+		})
+	}
+}
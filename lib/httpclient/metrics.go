@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Metrics records the http.client.request.duration histogram of every
+// attempt with OpenTelemetry. The global meter provider is used when
+// provider is nil.
+func Metrics(provider metric.MeterProvider) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		meterProvider := provider
+		if meterProvider == nil {
+			meterProvider = otel.GetMeterProvider()
+		}
+
+		duration, err := meterProvider.Meter(instrumentationName).Float64Histogram(
+			"http.client.request.duration",
+			metric.WithUnit("s"),
+			metric.WithDescription("Duration of HTTP client requests."),
+		)
+		if err != nil {
+			otel.Handle(err)
+		}
+
+		return RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.RoundTrip(request)
+
+			attributes := []attribute.KeyValue{
+				semconv.HTTPRequestMethodKey.String(request.Method),
+				semconv.ServerAddress(request.URL.Hostname()),
+			}
+			if err != nil {
+				attributes = append(attributes, semconv.ErrorTypeOther)
+			} else {
+				attributes = append(attributes, semconv.HTTPResponseStatusCode(response.StatusCode))
+				if response.StatusCode >= 400 {
+					attributes = append(attributes, semconv.ErrorTypeKey.String(strconv.Itoa(response.StatusCode)))
+				}
+			}
+			duration.Record(request.Context(), time.Since(start).Seconds(), metric.WithAttributes(attributes...))
+
+			return response, err
+		})
+	}
+}
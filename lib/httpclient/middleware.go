@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// Middleware wraps the transport of the client, in the style of an
+// http.RoundTripper decorator. It sees every attempt of a request, after the
+// body was encoded and before retries are decided.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripFunc adapts a function to an http.RoundTripper
+type RoundTripFunc func(request *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(request)
+func (f RoundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// chain : Wrap transport with middlewares, the first middleware is the outermost
+func chain(transport http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+
+	return transport
+}
+
+type attemptKey struct{}
+
+// Attempt returns the attempt number of the request a middleware is handling, starting at 1
+func Attempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+// TokenSource provides the bearer token of a request
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// RefreshableTokenSource is a TokenSource that can replace a token the server rejected
+type RefreshableTokenSource interface {
+	TokenSource
+	Refresh(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx)
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// BearerAuth sets the Authorization header of requests that have none to a
+// bearer token from tokens. When the server answers 401 and tokens is a
+// RefreshableTokenSource, the token is refreshed and the request sent once more.
+func BearerAuth(tokens TokenSource) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get("Authorization") != "" {
+				return next.RoundTrip(request)
+			}
+
+			token, err := tokens.Token(request.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			response, err := next.RoundTrip(withBearer(request, token))
+			if err != nil || response.StatusCode != http.StatusUnauthorized {
+				return response, err
+			}
+
+			refresher, ok := tokens.(RefreshableTokenSource)
+			if !ok || (request.Body != nil && request.Body != http.NoBody && request.GetBody == nil) {
+				return response, nil
+			}
+
+			token, err = refresher.Refresh(request.Context())
+			if err != nil {
+				return response, nil
+			}
+
+			retry := withBearer(request, token)
+			if request.GetBody != nil {
+				if retry.Body, err = request.GetBody(); err != nil {
+					return response, nil
+				}
+			}
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+
+			return next.RoundTrip(retry)
+		})
+	}
+}
+
+// withBearer : Copy a request with a bearer Authorization header, a RoundTripper must not modify its request
+func withBearer(request *http.Request, token string) *http.Request {
+	clone := request.Clone(request.Context())
+	clone.Header.Set("Authorization", "Bearer "+token)
+
+	return clone
+}
+
+// readBody : Read a body and return a reader that replays it, so middlewares can inspect it
+func readBody(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	if body == nil || body == http.NoBody {
+		return nil, body, nil
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// peekRequestBody : Return a copy of the request body when it can be read again, without consuming it
+func peekRequestBody(request *http.Request) []byte {
+	if request.GetBody == nil {
+		return nil
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	data, _ := io.ReadAll(body)
+	return data
+}
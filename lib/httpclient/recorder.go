@@ -0,0 +1,186 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RecorderMode selects whether a Recorder saves or serves interactions
+type RecorderMode int
+
+const (
+	// ModeRecord sends requests and saves every interaction
+	ModeRecord RecorderMode = iota
+	// ModeReplay serves saved interactions without touching the network
+	ModeReplay
+)
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the recorded part of a request
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Url    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is a record/replay transport for tests. In ModeRecord it saves
+// every interaction to a cassette file when Save is called, in ModeReplay it
+// answers requests from the cassette in order, matching method and URL.
+// Secrets are masked before interactions are kept, so cassettes can be
+// committed as test fixtures.
+type Recorder struct {
+	path     string
+	mode     RecorderMode
+	redactor Redactor
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Middleware returns the middleware recording or replaying requests
+func (r *Recorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+			if r.mode == ModeReplay {
+				return r.replay(request)
+			}
+			return r.record(next, request)
+		})
+	}
+}
+
+// Save writes the recorded interactions to the cassette file
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(r.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+// record : Private method to send a request and keep a copy of the interaction
+func (r *Recorder) record(next http.RoundTripper, request *http.Request) (*http.Response, error) {
+	requestBody := peekRequestBody(request)
+
+	response, err := next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, replay, err := readBody(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	response.Body = replay
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, Interaction{
+		Request: RecordedRequest{
+			Method: request.Method,
+			Url:    r.redactor.Url(request.URL.String()),
+			Body:   string(r.redactor.Body(request.Header.Get("Content-Type"), requestBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     r.redactor.Header(response.Header),
+			Body:       string(r.redactor.Body(response.Header.Get("Content-Type"), body)),
+		},
+	})
+
+	return response, nil
+}
+
+// replay : Private method to answer a request with the first unused matching interaction
+func (r *Recorder) replay(request *http.Request) (*http.Response, error) {
+	// cassettes hold masked URLs, so the request is matched after masking it too
+	requestUrl := r.redactor.Url(request.URL.String())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Request.Method != request.Method || interaction.Request.Url != requestUrl {
+			continue
+		}
+		r.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction for %s %s", request.Method, request.URL)
+}
+
+// NewRecorder creates a recorder backed by the cassette file at path. The
+// file must exist in ModeReplay. redactor masks the recorded secrets,
+// NewRedactor(DefaultRedactConfig()) when nil.
+func NewRecorder(path string, mode RecorderMode, redactor Redactor) (*Recorder, error) {
+	if redactor == nil {
+		redactor = NewRedactor(DefaultRedactConfig())
+	}
+
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		redactor: redactor,
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette: %w", err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	} else if mode != ModeRecord {
+		return nil, errors.New("unknown recorder mode")
+	}
+
+	return r, nil
+}
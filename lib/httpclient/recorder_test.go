@@ -0,0 +1,218 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderMasksSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+		w.Write([]byte(`{"access_token":"access-secret","refresh_token":"refresh-secret","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "token.json")
+	tokenUrl := srv.URL + "/login/oauth2/token?code=code-secret"
+	form := map[string]string{
+		"grant_type":       "authorization_code",
+		"client_id":        "10000000000001",
+		"client_secret":    "client-secret",
+		"client_assertion": "assertion-secret",
+	}
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+
+	recorder, err := NewRecorder(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewHttpClient(&Config{Middlewares: []Middleware{recorder.Middleware()}})
+
+	var result map[string]any
+	if err := client.Call(context.Background(), http.MethodPost, tokenUrl, headers, form, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result["access_token"] != "access-secret" {
+		t.Errorf("access_token = %v, want the caller to get the real token", result["access_token"])
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := string(data)
+	if !strings.Contains(cassette, "[REDACTED]") || !strings.Contains(cassette, "10000000000001") {
+		t.Errorf("cassette should keep the client id and mask the secrets:\n%s", cassette)
+	}
+	for _, secret := range []string{"code-secret", "client-secret", "assertion-secret", "access-secret", "refresh-secret", "cookie-secret"} {
+		if strings.Contains(cassette, secret) {
+			t.Errorf("cassette contains %s:\n%s", secret, cassette)
+		}
+	}
+
+	// the masked cassette still answers the same request
+	replayer, err := NewRecorder(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = NewHttpClient(&Config{Middlewares: []Middleware{replayer.Middleware()}})
+
+	result = nil
+	if err := client.Call(context.Background(), http.MethodPost, tokenUrl, headers, form, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result["access_token"] != "[REDACTED]" || result["token_type"] != "Bearer" {
+		t.Errorf("replayed result = %v", result)
+	}
+}
+
+func TestRecorderReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := `[
+		{"request": {"method": "GET", "url": "https://canvas.test/api/v1/courses/1"}, "response": {"status_code": 200, "header": {"Content-Type": ["application/json"]}, "body": "{\"id\":1}"}},
+		{"request": {"method": "GET", "url": "https://canvas.test/api/v1/courses/1"}, "response": {"status_code": 200, "header": {"Content-Type": ["application/json"]}, "body": "{\"id\":2}"}},
+		{"request": {"method": "DELETE", "url": "https://canvas.test/api/v1/courses/1"}, "response": {"status_code": 404, "header": {}, "body": ""}}
+	]`
+	if err := os.WriteFile(path, []byte(cassette), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder, err := NewRecorder(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := recorder.Middleware()(RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+		t.Fatalf("replay sent %s %s to the network", request.Method, request.URL)
+		return nil, nil
+	}))
+
+	tests := []struct {
+		method     string
+		url        string
+		wantStatus int
+		wantBody   string
+		wantErr    bool
+	}{
+		{http.MethodGet, "https://canvas.test/api/v1/courses/1", 200, `{"id":1}`, false},
+		{http.MethodGet, "https://canvas.test/api/v1/courses/1", 200, `{"id":2}`, false},
+		{http.MethodGet, "https://canvas.test/api/v1/courses/1", 0, "", true},
+		{http.MethodDelete, "https://canvas.test/api/v1/courses/1", 404, "", false},
+		{http.MethodGet, "https://canvas.test/api/v1/courses/2", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		request, _ := http.NewRequest(tt.method, tt.url, nil)
+		response, err := transport.RoundTrip(request)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s %s: want an error for an unrecorded request", tt.method, tt.url)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _, _ := readBody(response.Body)
+		if response.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+			t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.url, response.StatusCode, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				response, err := next.RoundTrip(request)
+				calls = append(calls, name+" after")
+				return response, err
+			})
+		}
+	}
+
+	transport := chain(RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+		calls = append(calls, "transport")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}), []Middleware{trace("outer"), trace("inner")})
+
+	request, _ := http.NewRequest(http.MethodGet, "https://canvas.test", nil)
+	if _, err := transport.RoundTrip(request); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"outer before", "inner before", "transport", "inner after", "outer after"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+type testTokens struct {
+	token     string
+	refreshed string
+}
+
+func (s *testTokens) Token(ctx context.Context) (string, error) {
+	return s.token, nil
+}
+
+func (s *testTokens) Refresh(ctx context.Context) (string, error) {
+	s.token = s.refreshed
+	return s.token, nil
+}
+
+func TestBearerAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		tokens        TokenSource
+		authorization string
+		rejected      string
+		want          []string
+	}{
+		{"sets the token", TokenSourceFunc(func(ctx context.Context) (string, error) { return "t1", nil }), "", "", []string{"Bearer t1"}},
+		{"keeps an existing header", TokenSourceFunc(func(ctx context.Context) (string, error) { return "t1", nil }), "Basic x", "", []string{"Basic x"}},
+		{"refreshes once on 401", &testTokens{token: "old", refreshed: "new"}, "", "Bearer old", []string{"Bearer old", "Bearer new"}},
+		{"no refresh without a refreshable source", TokenSourceFunc(func(ctx context.Context) (string, error) { return "old", nil }), "", "Bearer old", []string{"Bearer old"}},
+		{"refreshed token rejected again", &testTokens{token: "old", refreshed: "old"}, "", "Bearer old", []string{"Bearer old", "Bearer old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			transport := BearerAuth(tt.tokens)(RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+				sent = append(sent, request.Header.Get("Authorization"))
+				status := http.StatusOK
+				if request.Header.Get("Authorization") == tt.rejected {
+					status = http.StatusUnauthorized
+				}
+				return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+			}))
+
+			request, _ := http.NewRequest(http.MethodGet, "https://canvas.test", nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			if _, err := transport.RoundTrip(request); err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(sent, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sent %v, want %v", sent, tt.want)
+			}
+			if tt.authorization == "" && request.Header.Get("Authorization") != "" {
+				t.Errorf("the caller's request was modified")
+			}
+		})
+	}
+}
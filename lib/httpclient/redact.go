@@ -0,0 +1,247 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// RedactConfig lists the secrets masked before requests and responses are logged
+type RedactConfig struct {
+	// Headers are header names, matched case-insensitively. The scheme of
+	// Authorization headers is kept.
+	Headers []string
+	// BodyPaths are dot separated field paths in JSON and form bodies. "*"
+	// matches one field or array index and "**" any number of them, so
+	// "**.access_token" masks access_token at any depth. Form keys such as
+	// a[b][] are matched as the path a.b.
+	BodyPaths []string
+	// QueryKeys are URL query parameter names, matched case-insensitively
+	QueryKeys []string
+	// Mask replaces every secret
+	Mask string
+}
+
+// DefaultRedactConfig returns the LTI and OAuth2 secrets masked by default
+func DefaultRedactConfig() *RedactConfig {
+	return &RedactConfig{
+		Headers: []string{
+			"Authorization",
+			"Proxy-Authorization",
+			"Cookie",
+			"Set-Cookie",
+			"X-Api-Key",
+		},
+		BodyPaths: []string{
+			"**.access_token",
+			"**.refresh_token",
+			"**.id_token",
+			"**.client_secret",
+			"**.client_assertion",
+			"**.code",
+			"**.JWT",
+			"**.jwt",
+			"**.password",
+		},
+		QueryKeys: []string{
+			"access_token",
+			"refresh_token",
+			"id_token",
+			"client_secret",
+			"client_assertion",
+			"code",
+			"lti_session",
+			"lti_storage_value",
+			"password",
+		},
+		Mask: "[REDACTED]",
+	}
+}
+
+type redactor struct {
+	config    *RedactConfig
+	bodyPaths [][]string
+}
+
+// Url masks the configured query parameters of a URL
+func (r *redactor) Url(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.RawQuery == "" {
+		return rawUrl
+	}
+
+	query := u.Query()
+	changed := false
+	for key, values := range query {
+		if r.isQueryKey(key) {
+			for i := range values {
+				values[i] = r.config.Mask
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return rawUrl
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// Header returns a copy of header with the configured headers masked
+func (r *redactor) Header(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name, values := range redacted {
+		if !slices.ContainsFunc(r.config.Headers, func(h string) bool { return strings.EqualFold(h, name) }) {
+			continue
+		}
+
+		for i, value := range values {
+			if scheme, _, ok := strings.Cut(value, " "); ok && strings.EqualFold(name, "Authorization") {
+				values[i] = scheme + " " + r.config.Mask
+			} else {
+				values[i] = r.config.Mask
+			}
+		}
+	}
+
+	return redacted
+}
+
+// Body masks the configured fields of JSON and form bodies. Multipart bodies are not logged.
+func (r *redactor) Body(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == mimeForm:
+		return r.formBody(body)
+	case mediaType == mimeMultipart:
+		return []byte("[multipart body omitted]")
+	case mediaType == mimeJSON || strings.HasSuffix(mediaType, "+json"):
+		return r.jsonBody(body)
+	default:
+		// bodies without a usable content type are often JSON
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return r.jsonBody(body)
+		}
+		return body
+	}
+}
+
+func (r *redactor) jsonBody(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	redacted, err := json.Marshal(r.redactValue(nil, value))
+	if err != nil {
+		return body
+	}
+
+	return redacted
+}
+
+func (r *redactor) formBody(body []byte) []byte {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+
+	for key, items := range values {
+		if r.isBodyPath(formPath(key)) {
+			for i := range items {
+				items[i] = r.config.Mask
+			}
+		}
+	}
+
+	return []byte(values.Encode())
+}
+
+// redactValue : Walk a decoded JSON value and mask the fields matching a body path
+func (r *redactor) redactValue(path []string, value any) any {
+	if len(path) > 0 && r.isBodyPath(path) {
+		return r.config.Mask
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = r.redactValue(append(slices.Clip(path), key), item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.redactValue(append(slices.Clip(path), "*"), item)
+		}
+	}
+
+	return value
+}
+
+func (r *redactor) isBodyPath(path []string) bool {
+	for _, pattern := range r.bodyPaths {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *redactor) isQueryKey(key string) bool {
+	return slices.ContainsFunc(r.config.QueryKeys, func(k string) bool { return strings.EqualFold(k, key) })
+}
+
+// matchPath : Match a field path against a pattern where "*" is one segment and "**" any number of them
+func matchPath(pattern []string, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	switch pattern[0] {
+	case "**":
+		for i := 0; i <= len(path); i++ {
+			if matchPath(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(path) > 0 && matchPath(pattern[1:], path[1:])
+	default:
+		return len(path) > 0 && path[0] == pattern[0] && matchPath(pattern[1:], path[1:])
+	}
+}
+
+// formPath : Split a Rails style form key such as a[b][] into the path a.b
+func formPath(key string) []string {
+	return strings.FieldsFunc(key, func(c rune) bool { return c == '[' || c == ']' })
+}
+
+// NewRedactor creates a Redactor masking the secrets listed in config, DefaultRedactConfig when nil
+func NewRedactor(config *RedactConfig) Redactor {
+	if config == nil {
+		config = DefaultRedactConfig()
+	}
+	if config.Mask == "" {
+		config.Mask = "[REDACTED]"
+	}
+
+	bodyPaths := make([][]string, 0, len(config.BodyPaths))
+	for _, path := range config.BodyPaths {
+		bodyPaths = append(bodyPaths, strings.Split(path, "."))
+	}
+
+	return &redactor{
+		config:    config,
+		bodyPaths: bodyPaths,
+	}
+}
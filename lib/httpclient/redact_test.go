@@ -0,0 +1,241 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"access_token", "access_token", true},
+		{"access_token", "token", false},
+		{"access_token", "data.access_token", false},
+		{"**.access_token", "access_token", true},
+		{"**.access_token", "data.access_token", true},
+		{"**.access_token", "data.*.tokens.access_token", true},
+		{"**.access_token", "access_token.value", false},
+		{"**.access_token", "access_token_hint", false},
+		{"user.login_id", "user.login_id", true},
+		{"user.login_id", "user.name", false},
+		{"user.login_id", "user", false},
+		{"items.*.secret", "items.*.secret", true},
+		{"items.*.secret", "items.0.secret", true},
+		{"items.*.secret", "items.secret", false},
+		{"items.*.secret", "items.0.1.secret", false},
+		{"a.**", "a", true},
+		{"a.**", "a.b.c", true},
+		{"a.**.z", "a.z", true},
+		{"a.**.z", "a.b.c.z", true},
+		{"a.**.z", "a.b.c", false},
+		{"*", "a", true},
+		{"*", "a.b", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPath(strings.Split(tt.pattern, "."), strings.Split(tt.path, ".")); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFormPath(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"client_secret", []string{"client_secret"}},
+		{"user[password]", []string{"user", "password"}},
+		{"tokens[][access_token]", []string{"tokens", "access_token"}},
+		{"items[0][secret]", []string{"items", "0", "secret"}},
+	}
+
+	for _, tt := range tests {
+		if got := formPath(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("formPath(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorBody(t *testing.T) {
+	config := DefaultRedactConfig()
+	config.BodyPaths = append(config.BodyPaths, "items.*.secret")
+	redactor := NewRedactor(config)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "json top level",
+			contentType: "application/json",
+			body:        `{"access_token":"abc","token_type":"Bearer","expires_in":3600}`,
+			want:        `{"access_token":"[REDACTED]","expires_in":3600,"token_type":"Bearer"}`,
+		},
+		{
+			name:        "json nested",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"user":{"name":"A","password":"p"},"grants":[{"refresh_token":"r"}]}`,
+			want:        `{"grants":[{"refresh_token":"[REDACTED]"}],"user":{"name":"A","password":"[REDACTED]"}}`,
+		},
+		{
+			name:        "json array wildcard",
+			contentType: "application/json",
+			body:        `{"items":[{"secret":"s","id":1}],"secret":"kept"}`,
+			want:        `{"items":[{"id":1,"secret":"[REDACTED]"}],"secret":"kept"}`,
+		},
+		{
+			name:        "json object value",
+			contentType: "application/vnd.ims.lis.v1.score+json",
+			body:        `{"jwt":{"header":"h"}}`,
+			want:        `{"jwt":"[REDACTED]"}`,
+		},
+		{
+			name:        "json without content type",
+			contentType: "",
+			body:        ` {"id_token":"t"}`,
+			want:        `{"id_token":"[REDACTED]"}`,
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials&client_assertion=jwt&scope=a+b",
+			want:        "client_assertion=%5BREDACTED%5D&grant_type=client_credentials&scope=a+b",
+		},
+		{
+			name:        "nested form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "user%5Bpassword%5D=p&user%5Bname%5D=A",
+			want:        "user%5Bname%5D=A&user%5Bpassword%5D=%5BREDACTED%5D",
+		},
+		{
+			name:        "multipart",
+			contentType: "multipart/form-data; boundary=x",
+			body:        "--x\r\n",
+			want:        "[multipart body omitted]",
+		},
+		{
+			name:        "plain text",
+			contentType: "text/plain",
+			body:        "access_token=abc",
+			want:        "access_token=abc",
+		},
+		{
+			name:        "invalid json",
+			contentType: "application/json",
+			body:        `{"access_token":`,
+			want:        `{"access_token":`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactor.Body(tt.contentType, []byte(tt.body))); got != tt.want {
+				t.Errorf("Body(%q) = %s, want %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorJsonNumbers(t *testing.T) {
+	got := NewRedactor(nil).Body("application/json", []byte(`{"id":12345678901234567890,"code":"c"}`))
+
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded["id"]) != "12345678901234567890" || string(decoded["code"]) != `"[REDACTED]"` {
+		t.Errorf("Body = %s, want the id kept exactly and the code masked", got)
+	}
+}
+
+func TestRedactorHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Set("Cookie", "session=s")
+	header.Set("Accept", "application/json")
+
+	redacted := NewRedactor(nil).Header(header)
+
+	if got := redacted.Get("Authorization"); got != "Bearer [REDACTED]" {
+		t.Errorf("Authorization = %q, want the scheme kept", got)
+	}
+	if got := redacted.Get("Cookie"); got != "[REDACTED]" {
+		t.Errorf("Cookie = %q, want it masked", got)
+	}
+	if got := redacted.Get("Accept"); got != "application/json" {
+		t.Errorf("Accept = %q, want it kept", got)
+	}
+	if got := header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("original Authorization = %q, want it untouched", got)
+	}
+}
+
+func TestRedactorUrl(t *testing.T) {
+	redactor := NewRedactor(nil)
+
+	tests := []struct {
+		url  string
+		want url.Values
+	}{
+		{"https://canvas.test/api/v1/courses?per_page=10", url.Values{"per_page": {"10"}}},
+		{"https://tool.test/app?lti_session=s&page=2", url.Values{"lti_session": {"[REDACTED]"}, "page": {"2"}}},
+		{"https://canvas.test/login/oauth2/token?Code=c", url.Values{"Code": {"[REDACTED]"}}},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(redactor.Url(tt.url))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := u.Query(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Url(%q) query = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestLoggingRedactsByDefault(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&out).Level(zerolog.DebugLevel)
+	defer func() { log.Logger = logger }()
+
+	transport := Logging(LoggingOptions{Bodies: true})(RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"access_token":"response-secret"}`)),
+		}, nil
+	}))
+
+	request, _ := http.NewRequest(http.MethodPost, "https://canvas.test/login/oauth2/token?code=query-secret",
+		strings.NewReader("client_secret=body-secret"))
+	request.Header.Set("Authorization", "Bearer header-secret")
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := transport.RoundTrip(request); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "[REDACTED]") {
+		t.Fatalf("nothing was logged: %s", out.String())
+	}
+	for _, secret := range []string{"query-secret", "body-secret", "header-secret", "response-secret"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("log contains %s:\n%s", secret, out.String())
+		}
+	}
+}
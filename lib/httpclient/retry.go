@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRetryStatusCodes are the response statuses retried by default
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultIdempotentMethods are the methods that are safe to send twice
+var DefaultIdempotentMethods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodDelete,
+}
+
+// shouldRetry : Decide whether a failed attempt is retried. Idempotent methods are retried on
+// transport errors and retryable statuses, other methods only when the server rejected the
+// request without processing it (429) or when the request carries an Idempotency-Key.
+func (h *httpClient) shouldRetry(method string, headers map[string]string, statusCode int, err error) bool {
+	idempotent := slices.Contains(h.config.IdempotentMethods, method) || headerValue(headers, "Idempotency-Key") != ""
+
+	if err != nil {
+		return idempotent
+	}
+	if !slices.Contains(h.config.RetryStatusCodes, statusCode) {
+		return false
+	}
+
+	return idempotent || statusCode == http.StatusTooManyRequests
+}
+
+// backoff : Exponential wait before the given retry, capped by MaxRetryWaitTime and spread by Jitter
+func (h *httpClient) backoff(retry int) time.Duration {
+	wait := h.config.RetryWaitTime
+	for i := 1; i < retry && wait < h.config.MaxRetryWaitTime; i++ {
+		wait *= 2
+	}
+	wait = min(wait, h.config.MaxRetryWaitTime)
+
+	if h.config.Jitter > 0 && wait > 0 {
+		spread := time.Duration(float64(wait) * h.config.Jitter)
+		wait += rand.N(2*spread+1) - spread
+	}
+
+	return max(wait, 0)
+}
+
+// retryAfter : Parse a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	client := &httpClient{config: DefaultConfig()}
+	errTransport := errors.New("connection reset")
+
+	tests := []struct {
+		name       string
+		method     string
+		headers    map[string]string
+		statusCode int
+		err        error
+		want       bool
+	}{
+		{"get on transport error", http.MethodGet, nil, 0, errTransport, true},
+		{"post on transport error", http.MethodPost, nil, 0, errTransport, false},
+		{"post with idempotency key on transport error", http.MethodPost, map[string]string{"Idempotency-Key": "k"}, 0, errTransport, true},
+		{"idempotency key header is case insensitive", http.MethodPost, map[string]string{"idempotency-key": "k"}, 0, errTransport, true},
+		{"get on 503", http.MethodGet, nil, http.StatusServiceUnavailable, nil, true},
+		{"put on 500", http.MethodPut, nil, http.StatusInternalServerError, nil, true},
+		{"delete on 504", http.MethodDelete, nil, http.StatusGatewayTimeout, nil, true},
+		{"post on 503", http.MethodPost, nil, http.StatusServiceUnavailable, nil, false},
+		{"patch on 502", http.MethodPatch, nil, http.StatusBadGateway, nil, false},
+		{"post on 429", http.MethodPost, nil, http.StatusTooManyRequests, nil, true},
+		{"post with idempotency key on 502", http.MethodPost, map[string]string{"Idempotency-Key": "k"}, http.StatusBadGateway, nil, true},
+		{"get on 404", http.MethodGet, nil, http.StatusNotFound, nil, false},
+		{"get on 401", http.MethodGet, nil, http.StatusUnauthorized, nil, false},
+		{"get on 501", http.MethodGet, nil, http.StatusNotImplemented, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := client.shouldRetry(tt.method, tt.headers, tt.statusCode, tt.err); got != tt.want {
+				t.Errorf("shouldRetry(%s, %v, %d, %v) = %v, want %v", tt.method, tt.headers, tt.statusCode, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	client := &httpClient{config: &Config{
+		RetryWaitTime:    1 * time.Second,
+		MaxRetryWaitTime: 10 * time.Second,
+	}}
+
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := client.backoff(tt.retry); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.retry, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	client := &httpClient{config: &Config{
+		RetryWaitTime:    4 * time.Second,
+		MaxRetryWaitTime: 10 * time.Second,
+		Jitter:           0.25,
+	}}
+
+	for range 1000 {
+		if got := client.backoff(1); got < 3*time.Second || got > 5*time.Second {
+			t.Fatalf("backoff(1) = %s, want within 25%% of 4s", got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{"missing", "", 0, false},
+		{"seconds", "120", 120 * time.Second, true},
+		{"padded seconds", " 3 ", 3 * time.Second, true},
+		{"negative seconds", "-5", 0, true},
+		{"date in the past", "Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+		{"invalid", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.value != "" {
+				header.Set("Retry-After", tt.value)
+			}
+
+			got, ok := retryAfter(header)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRetryAfterDate(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", time.Now().Add(30*time.Second).UTC().Format(http.TimeFormat))
+
+	got, ok := retryAfter(header)
+	if !ok || got <= 25*time.Second || got > 30*time.Second {
+		t.Errorf("retryAfter(date in 30s) = %s, %v, want about 30s", got, ok)
+	}
+}
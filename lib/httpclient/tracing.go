@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "go-lti/lib/httpclient"
+
+// Tracing starts an OpenTelemetry client span for every attempt and
+// propagates the trace context in the request headers. The global tracer
+// provider and propagator are used when provider or propagator are nil.
+func Tracing(provider trace.TracerProvider, propagator propagation.TextMapPropagator) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+			tracerProvider := provider
+			if tracerProvider == nil {
+				tracerProvider = otel.GetTracerProvider()
+			}
+			textMapPropagator := propagator
+			if textMapPropagator == nil {
+				textMapPropagator = otel.GetTextMapPropagator()
+			}
+
+			ctx, span := tracerProvider.Tracer(instrumentationName).Start(request.Context(), request.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(request.Method),
+					semconv.URLFull(request.URL.Redacted()),
+					semconv.ServerAddress(request.URL.Hostname()),
+					semconv.HTTPRequestResendCount(max(Attempt(request.Context())-1, 0)),
+				),
+			)
+			defer span.End()
+
+			request = request.Clone(ctx)
+			textMapPropagator.Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, err
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(response.StatusCode))
+			if response.StatusCode >= 400 {
+				span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", response.StatusCode))
+			}
+
+			return response, nil
+		})
+	}
+}
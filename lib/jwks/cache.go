@@ -0,0 +1,209 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-lti/lib/httpclient"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/rs/zerolog/log"
+)
+
+// Config holds the configuration for the JWKS cache
+type Config struct {
+	// DefaultTTL is used when the response has no caching headers
+	DefaultTTL time.Duration
+	// MinTTL and MaxTTL bound the lifetime taken from Cache-Control or Expires
+	MinTTL time.Duration
+	MaxTTL time.Duration
+	// RefreshAhead is the fraction of the TTL after which a background refresh starts
+	RefreshAhead float64
+	// MinRefreshInterval limits forced refetches for unknown key ids
+	MinRefreshInterval time.Duration
+	// FetchTimeout bounds background refreshes
+	FetchTimeout time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() *Config {
+	return &Config{
+		DefaultTTL:         1 * time.Hour,
+		MinTTL:             1 * time.Minute,
+		MaxTTL:             24 * time.Hour,
+		RefreshAhead:       0.8,
+		MinRefreshInterval: 30 * time.Second,
+		FetchTimeout:       10 * time.Second,
+	}
+}
+
+// Cache keeps the JSON Web Key Set of each platform in memory. It is safe
+// for concurrent use.
+type Cache struct {
+	httpClient httpclient.HttpClient
+	config     *Config
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	// mu serializes fetches so concurrent callers share one request
+	mu         sync.Mutex
+	set        atomic.Pointer[jwk.Set]
+	fetchedAt  atomic.Int64
+	expiresAt  atomic.Int64
+	refreshing atomic.Bool
+}
+
+// Get returns the key set published at url, fetching it when missing or expired.
+func (c *Cache) Get(ctx context.Context, url string) (jwk.Set, error) {
+	e := c.entry(url)
+
+	if set := e.set.Load(); set != nil {
+		now := time.Now()
+		expiresAt := time.Unix(0, e.expiresAt.Load())
+		if now.Before(expiresAt) {
+			fetchedAt := time.Unix(0, e.fetchedAt.Load())
+			refreshAt := fetchedAt.Add(time.Duration(float64(expiresAt.Sub(fetchedAt)) * c.config.RefreshAhead))
+			if now.After(refreshAt) {
+				c.refreshInBackground(url, e)
+			}
+			return *set, nil
+		}
+	}
+
+	return c.fetch(ctx, url, e, false)
+}
+
+// Lookup returns the key set published at url and makes sure it was
+// refetched once when kid is not in the cached set, so rotated platform keys
+// are picked up without waiting for expiry.
+func (c *Cache) Lookup(ctx context.Context, url string, kid string) (jwk.Set, error) {
+	set, err := c.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if kid == "" {
+		return set, nil
+	}
+	if _, ok := set.LookupKeyID(kid); ok {
+		return set, nil
+	}
+
+	return c.fetch(ctx, url, c.entry(url), true)
+}
+
+// entry : Return the cache entry for url, creating it when needed
+func (c *Cache) entry(url string) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[url]
+	if !ok {
+		e = &entry{}
+		c.entries[url] = e
+	}
+
+	return e
+}
+
+// fetch : Download the key set unless another caller refreshed it meanwhile.
+// Forced fetches still respect MinRefreshInterval.
+func (c *Cache) fetch(ctx context.Context, url string, e *entry, force bool) (jwk.Set, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if set := e.set.Load(); set != nil {
+		fetchedAt := time.Unix(0, e.fetchedAt.Load())
+		if force && time.Since(fetchedAt) < c.config.MinRefreshInterval {
+			return *set, nil
+		}
+		if !force && time.Now().Before(time.Unix(0, e.expiresAt.Load())) {
+			return *set, nil
+		}
+	}
+
+	var raw json.RawMessage
+	res, err := c.httpClient.Do(ctx, http.MethodGet, url, map[string]string{
+		"Accept": "application/json",
+	}, nil, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+
+	set, err := jwk.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	now := time.Now()
+	e.set.Store(&set)
+	e.fetchedAt.Store(now.UnixNano())
+	e.expiresAt.Store(now.Add(c.ttl(res.Header)).UnixNano())
+
+	return set, nil
+}
+
+// refreshInBackground : Start a single background refresh of an entry that is close to expiry
+func (c *Cache) refreshInBackground(url string, e *entry) {
+	if !e.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer e.refreshing.Store(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.config.FetchTimeout)
+		defer cancel()
+
+		if _, err := c.fetch(ctx, url, e, true); err != nil {
+			log.Warn().Err(err).Str("url", url).Msg("Failed to refresh jwks")
+		}
+	}()
+}
+
+// ttl : Derive the cache lifetime from Cache-Control max-age or Expires
+func (c *Cache) ttl(header http.Header) time.Duration {
+	ttl := c.config.DefaultTTL
+
+	if cacheControl := header.Get("Cache-Control"); cacheControl != "" {
+		for _, directive := range strings.Split(cacheControl, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "no-store", "no-cache":
+				return c.config.MinTTL
+			case "max-age":
+				if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+					ttl = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+	} else if expires := header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			ttl = time.Until(t)
+		}
+	}
+
+	return min(max(ttl, c.config.MinTTL), c.config.MaxTTL)
+}
+
+// NewCache creates a JWKS cache that fetches key sets with the given client.
+func NewCache(httpClient httpclient.HttpClient, config *Config) *Cache {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	return &Cache{
+		httpClient: httpClient,
+		config:     config,
+		entries:    make(map[string]*entry),
+	}
+}
@@ -0,0 +1,413 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrUnknownKey is returned when no loaded key has the requested key id
+	ErrUnknownKey = errors.New("unknown tool key")
+	// ErrUnsupportedAlgorithm is returned when a key cannot sign with the requested algorithm
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+)
+
+// minRsaBits is the smallest RSA modulus accepted for signing
+const minRsaBits = 2048
+
+const (
+	// DefaultRotationDelay gives platforms a day to refresh their cached copy of the tool JWKS
+	DefaultRotationDelay = 24 * time.Hour
+	// DefaultRetireAfter outlives the tokens signed with a replaced key
+	DefaultRetireAfter = 1 * time.Hour
+
+	ringReloadInterval = 30 * time.Second
+)
+
+// Config holds the location of the tool keys
+type Config struct {
+	// PrivateKeyPath is a PEM file with a PKCS#1, SEC 1 or PKCS#8 RSA or EC private key
+	PrivateKeyPath string
+	// PublicKeyPath is optional, when set it must hold the public key of PrivateKeyPath
+	PublicKeyPath string
+	// KeyId is the kid of the key, the RFC 7638 thumbprint when empty
+	KeyId string
+	// Algorithm is the JWS algorithm of the key: RS256, RS384, RS512, PS256, PS384 or PS512
+	// for RSA keys and the ES algorithm of the curve for EC keys. Defaults to RS256 or ES*.
+	Algorithm string
+	// RingPath is the JSON file of the key ring, a single key is used when empty
+	RingPath string
+	// RotationDelay is how long a staged key is published before it signs
+	RotationDelay time.Duration
+	// RetireAfter is how long a replaced key stays published
+	RetireAfter time.Duration
+}
+
+// Key is a tool signing key with its public JWK
+type Key struct {
+	Id        string
+	Algorithm jwa.SignatureAlgorithm
+	// ActivatesAt is when the key starts signing, it is published before
+	ActivatesAt time.Time
+	// ExpiresAt is when the key is removed from the JWKS, zero when it is not scheduled
+	ExpiresAt time.Time
+
+	private jwk.Key
+	public  jwk.Key
+	// path is the private key file, relative to the ring file
+	path string
+}
+
+// Public returns the public JWK of the key, with kid, alg and use set
+func (k *Key) Public() jwk.Key {
+	return k.public
+}
+
+// Sign signs token with the key, setting its kid header
+func (k *Key) Sign(token jwt.Token) (string, error) {
+	signed, err := jwt.Sign(token, jwt.WithKey(k.Algorithm, k.private))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return string(signed), nil
+}
+
+// published : Whether the key is in the JWKS at now
+func (k *Key) published(now time.Time) bool {
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// Manager holds the tool keys, loaded and validated once at startup. With a
+// ring file it publishes the next, current and retiring keys and signs with
+// the current one, following the schedule of the ring. It is safe for
+// concurrent use.
+type Manager struct {
+	config Config
+
+	mu        sync.Mutex
+	keys      []*Key
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// KeyId returns the kid of the key used to sign when no kid is given
+func (m *Manager) KeyId() string {
+	return m.current(time.Now()).Id
+}
+
+// Key returns the published key with the given kid, the current key when kid is empty
+func (m *Manager) Key(kid string) (*Key, error) {
+	now := time.Now()
+	if kid == "" {
+		return m.current(now), nil
+	}
+
+	for _, key := range m.ring() {
+		if key.Id == kid && key.published(now) {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+}
+
+// Sign signs token with the key with the given kid, the current key when kid is empty
+func (m *Manager) Sign(kid string, token jwt.Token) (string, error) {
+	key, err := m.Key(kid)
+	if err != nil {
+		return "", err
+	}
+
+	return key.Sign(token)
+}
+
+// PublicKeys returns the public JWKs of the published keys, for the tool JWKS
+func (m *Manager) PublicKeys() []jwk.Key {
+	now := time.Now()
+
+	var keys []jwk.Key
+	for _, key := range m.ring() {
+		if key.published(now) {
+			keys = append(keys, key.public)
+		}
+	}
+
+	return keys
+}
+
+// current : Private method to return the current key of the ring at now
+func (m *Manager) current(now time.Time) *Key {
+	return currentKey(m.ring(), now)
+}
+
+// currentKey : The published key with the latest activation that is not in the future. A ring
+// always has one, since keys only expire once the next key activated.
+func currentKey(keys []*Key, now time.Time) *Key {
+	var current *Key
+	for _, key := range keys {
+		if !key.published(now) || key.ActivatesAt.After(now) {
+			continue
+		}
+		if current == nil || !key.ActivatesAt.Before(current.ActivatesAt) {
+			current = key
+		}
+	}
+
+	return current
+}
+
+// ring : The keys of the manager, reloading the ring file when it changed
+func (m *Manager) ring() []*Key {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.RingPath != "" && time.Since(m.checkedAt) >= ringReloadInterval {
+		m.checkedAt = time.Now()
+		if err := m.reload(); err != nil {
+			log.Warn().Err(err).Str("path", m.config.RingPath).Msg("Failed to reload key ring, keeping the loaded keys")
+		}
+	}
+
+	return m.keys
+}
+
+// NewKey builds a signing key from a private key. kid defaults to the thumbprint of the public key
+// and algorithm to RS256 for RSA keys and the ECDSA algorithm of the curve for EC keys.
+func NewKey(privateKey crypto.Signer, kid string, algorithm jwa.SignatureAlgorithm) (*Key, error) {
+	algorithm, err := algorithmFor(privateKey, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	public, err := jwk.FromRaw(privateKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create public key: %w", err)
+	}
+	if kid == "" {
+		thumbprint, err := public.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute key thumbprint: %w", err)
+		}
+		kid = base64.RawURLEncoding.EncodeToString(thumbprint)
+	}
+
+	private, err := jwk.FromRaw(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create private key: %w", err)
+	}
+
+	for _, key := range []jwk.Key{public, private} {
+		key.Set(jwk.KeyIDKey, kid)
+		key.Set(jwk.AlgorithmKey, algorithm)
+		key.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	}
+
+	return &Key{
+		Id:        kid,
+		Algorithm: algorithm,
+		private:   private,
+		public:    public,
+	}, nil
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS#1, SEC 1 or PKCS#8 RSA or EC private key
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid RSA private key: %w", err)
+		}
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+}
+
+// ParsePublicKey parses a PEM encoded PKIX or PKCS#1 public key
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+}
+
+// algorithmFor : Check that a signature algorithm suits a private key, the default of the key type when empty
+func algorithmFor(privateKey crypto.Signer, algorithm jwa.SignatureAlgorithm) (jwa.SignatureAlgorithm, error) {
+	var supported []jwa.SignatureAlgorithm
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRsaBits {
+			return "", fmt.Errorf("RSA key of %d bits is too small, at least %d are required", key.N.BitLen(), minRsaBits)
+		}
+		supported = []jwa.SignatureAlgorithm{jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512}
+	case *ecdsa.PrivateKey:
+		// ECDSA algorithms are bound to a curve
+		switch key.Curve {
+		case elliptic.P256():
+			supported = []jwa.SignatureAlgorithm{jwa.ES256}
+		case elliptic.P384():
+			supported = []jwa.SignatureAlgorithm{jwa.ES384}
+		case elliptic.P521():
+			supported = []jwa.SignatureAlgorithm{jwa.ES512}
+		default:
+			return "", fmt.Errorf("unsupported EC curve: %s", key.Curve.Params().Name)
+		}
+	default:
+		return "", fmt.Errorf("unsupported private key type: %T", privateKey)
+	}
+
+	if algorithm == "" {
+		return supported[0], nil
+	}
+	if !slices.Contains(supported, algorithm) {
+		return "", fmt.Errorf("%w: %s cannot sign with %s keys", ErrUnsupportedAlgorithm, algorithm, keyType(privateKey))
+	}
+
+	return algorithm, nil
+}
+
+// keyType : Name of the type of a private key for error messages
+func keyType(privateKey crypto.Signer) string {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return "RSA"
+	case *ecdsa.PrivateKey:
+		return "EC " + key.Curve.Params().Name
+	default:
+		return fmt.Sprintf("%T", privateKey)
+	}
+}
+
+// NewManager loads and validates the tool keys described by config. Without
+// a ring file the manager holds the single key at PrivateKeyPath, with one it
+// holds the keys of the ring, which is created from PrivateKeyPath when it
+// does not exist.
+func NewManager(config Config) (*Manager, error) {
+	if config.RotationDelay <= 0 {
+		config.RotationDelay = DefaultRotationDelay
+	}
+	if config.RetireAfter <= 0 {
+		config.RetireAfter = DefaultRetireAfter
+	}
+
+	m := &Manager{config: config}
+
+	if config.RingPath != "" {
+		_, err := os.Stat(config.RingPath)
+		if err == nil {
+			if err := m.reload(); err != nil {
+				return nil, err
+			}
+			m.checkedAt = time.Now()
+			return m, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read key ring: %w", err)
+		}
+	}
+
+	key, err := loadKey(config)
+	if err != nil {
+		return nil, err
+	}
+	m.keys = []*Key{key}
+
+	if config.RingPath != "" {
+		// the ring refers to the key file, relative paths are resolved against the ring directory
+		if key.path, err = filepath.Abs(config.PrivateKeyPath); err != nil {
+			return nil, err
+		}
+		key.ActivatesAt = time.Now()
+		if err := m.save(m.keys); err != nil {
+			return nil, err
+		}
+		m.checkedAt = time.Now()
+	}
+
+	return m, nil
+}
+
+// loadKey : Load the key at PrivateKeyPath, checking it against PublicKeyPath when set
+func loadKey(config Config) (*Key, error) {
+	if config.PrivateKeyPath == "" {
+		return nil, errors.New("private key path is not set")
+	}
+
+	data, err := os.ReadFile(config.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	privateKey, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.PrivateKeyPath, err)
+	}
+
+	// the public key is derived, a configured public key file must belong to the private key
+	if config.PublicKeyPath != "" {
+		data, err := os.ReadFile(config.PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		publicKey, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", config.PublicKeyPath, err)
+		}
+		if equal, ok := publicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !equal.Equal(privateKey.Public()) {
+			return nil, fmt.Errorf("%s is not the public key of %s", config.PublicKeyPath, config.PrivateKeyPath)
+		}
+	}
+
+	return NewKey(privateKey, config.KeyId, jwa.SignatureAlgorithm(config.Algorithm))
+}
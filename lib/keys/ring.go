@@ -0,0 +1,321 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+)
+
+var (
+	// ErrNoRing is returned when keys are staged without a ring file
+	ErrNoRing = errors.New("key ring is not configured")
+	// ErrInvalidActivation is returned when a staged key would activate before the current key
+	ErrInvalidActivation = errors.New("staged key must activate after the current key")
+)
+
+// KeyStatus is the place of a key in the rotation
+type KeyStatus string
+
+const (
+	// StatusNext keys are published and sign once they activate
+	StatusNext KeyStatus = "next"
+	// StatusCurrent is the key that signs
+	StatusCurrent KeyStatus = "current"
+	// StatusRetiring keys were replaced and are published until they expire
+	StatusRetiring KeyStatus = "retiring"
+)
+
+// KeyInfo describes a published key of the ring
+type KeyInfo struct {
+	KeyId       string     `json:"kid"`
+	Algorithm   string     `json:"alg"`
+	Status      KeyStatus  `json:"status"`
+	ActivatesAt time.Time  `json:"activates_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// ringEntry is a key in the ring file
+type ringEntry struct {
+	KeyId          string     `json:"kid"`
+	Algorithm      string     `json:"alg"`
+	PrivateKeyPath string     `json:"private_key_path"`
+	ActivatesAt    time.Time  `json:"activates_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// Keys returns the published keys with their status, the current key first
+func (m *Manager) Keys() []KeyInfo {
+	now := time.Now()
+	current := m.current(now)
+
+	var infos []KeyInfo
+	for _, key := range m.ring() {
+		if !key.published(now) {
+			continue
+		}
+
+		info := KeyInfo{
+			KeyId:       key.Id,
+			Algorithm:   key.Algorithm.String(),
+			Status:      StatusRetiring,
+			ActivatesAt: key.ActivatesAt,
+		}
+		switch {
+		case key == current:
+			info.Status = StatusCurrent
+		case key.ActivatesAt.After(now):
+			info.Status = StatusNext
+		}
+		if !key.ExpiresAt.IsZero() {
+			expiresAt := key.ExpiresAt
+			info.ExpiresAt = &expiresAt
+		}
+		infos = append(infos, info)
+	}
+
+	slices.SortStableFunc(infos, func(a, b KeyInfo) int {
+		return statusOrder(a.Status) - statusOrder(b.Status)
+	})
+
+	return infos
+}
+
+// Stage generates a key pair for algorithm, the algorithm of the current key
+// when empty, and adds it to the ring. The key is published right away and
+// signs from activatesAt, RotationDelay from now when zero. Every key of the
+// ring expires RetireAfter after the key following it activated.
+func (m *Manager) Stage(activatesAt time.Time, algorithm jwa.SignatureAlgorithm) (*KeyInfo, error) {
+	if m.config.RingPath == "" {
+		return nil, ErrNoRing
+	}
+
+	now := time.Now()
+	if activatesAt.IsZero() {
+		activatesAt = now.Add(m.config.RotationDelay)
+	}
+	if algorithm == "" {
+		algorithm = m.current(now).Algorithm
+	}
+
+	privateKey, err := generateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewKey(privateKey, "", algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key.ActivatesAt = activatesAt
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// pick up keys staged by another process before rewriting the ring
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	if current := currentKey(m.keys, now); current != nil && activatesAt.Before(current.ActivatesAt) {
+		return nil, ErrInvalidActivation
+	}
+
+	key.path = "key-" + key.Id + ".pem"
+	if err := writePrivateKey(filepath.Join(filepath.Dir(m.config.RingPath), key.path), privateKey); err != nil {
+		return nil, err
+	}
+
+	keys := []*Key{key}
+	for _, existing := range m.keys {
+		// drop expired keys from the ring, the others are copied since readers hold them
+		if existing.published(now) {
+			copied := *existing
+			keys = append(keys, &copied)
+		}
+	}
+	slices.SortStableFunc(keys, func(a, b *Key) int {
+		return a.ActivatesAt.Compare(b.ActivatesAt)
+	})
+
+	// every key is retired once the key after it took over
+	for i, existing := range keys[:len(keys)-1] {
+		retireAt := keys[i+1].ActivatesAt.Add(m.config.RetireAfter)
+		if existing.ExpiresAt.IsZero() || existing.ExpiresAt.After(retireAt) {
+			existing.ExpiresAt = retireAt
+		}
+	}
+
+	if err := m.save(keys); err != nil {
+		return nil, err
+	}
+	m.keys = keys
+
+	info := &KeyInfo{
+		KeyId:       key.Id,
+		Algorithm:   key.Algorithm.String(),
+		Status:      StatusNext,
+		ActivatesAt: key.ActivatesAt,
+	}
+	if !activatesAt.After(now) {
+		info.Status = StatusCurrent
+	}
+
+	return info, nil
+}
+
+// reload : Private method to read the ring file when it changed since it was loaded, the caller holds mu
+func (m *Manager) reload() error {
+	info, err := os.Stat(m.config.RingPath)
+	if err != nil {
+		return fmt.Errorf("failed to read key ring: %w", err)
+	}
+	if info.ModTime().Equal(m.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.config.RingPath)
+	if err != nil {
+		return fmt.Errorf("failed to read key ring: %w", err)
+	}
+
+	var entries []ringEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse key ring: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(entries))
+	for _, entry := range entries {
+		path := entry.PrivateKeyPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(m.config.RingPath), path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read private key of %s: %w", entry.KeyId, err)
+		}
+		privateKey, err := ParsePrivateKey(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		key, err := NewKey(privateKey, entry.KeyId, jwa.SignatureAlgorithm(entry.Algorithm))
+		if err != nil {
+			return err
+		}
+		key.ActivatesAt = entry.ActivatesAt
+		if entry.ExpiresAt != nil {
+			key.ExpiresAt = *entry.ExpiresAt
+		}
+		key.path = entry.PrivateKeyPath
+		keys = append(keys, key)
+	}
+
+	if currentKey(keys, time.Now()) == nil {
+		return errors.New("key ring has no current key")
+	}
+	m.keys = keys
+	m.modTime = info.ModTime()
+
+	return nil
+}
+
+// save : Private method to atomically replace the ring file, the caller holds mu
+func (m *Manager) save(keys []*Key) error {
+	entries := make([]ringEntry, 0, len(keys))
+	for _, key := range keys {
+		entry := ringEntry{
+			KeyId:          key.Id,
+			Algorithm:      key.Algorithm.String(),
+			PrivateKeyPath: key.path,
+			ActivatesAt:    key.ActivatesAt,
+		}
+		if !key.ExpiresAt.IsZero() {
+			entry.ExpiresAt = &key.ExpiresAt
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode key ring: %w", err)
+	}
+
+	tmp := m.config.RingPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write key ring: %w", err)
+	}
+	if err := os.Rename(tmp, m.config.RingPath); err != nil {
+		return fmt.Errorf("failed to write key ring: %w", err)
+	}
+
+	info, err := os.Stat(m.config.RingPath)
+	if err != nil {
+		return fmt.Errorf("failed to read key ring: %w", err)
+	}
+	m.modTime = info.ModTime()
+
+	return nil
+}
+
+// generateKey : Generate a private key able to sign with algorithm
+func generateKey(algorithm jwa.SignatureAlgorithm) (crypto.Signer, error) {
+	var (
+		privateKey crypto.Signer
+		err        error
+	)
+	switch algorithm {
+	case jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512:
+		privateKey, err = rsa.GenerateKey(rand.Reader, minRsaBits)
+	case jwa.ES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwa.ES384:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case jwa.ES512:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return privateKey, nil
+}
+
+// writePrivateKey : Write a private key as a PKCS#8 PEM file readable only by its owner
+func writePrivateKey(path string, privateKey any) error {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	return nil
+}
+
+func statusOrder(status KeyStatus) int {
+	switch status {
+	case StatusCurrent:
+		return 0
+	case StatusNext:
+		return 1
+	default:
+		return 2
+	}
+}
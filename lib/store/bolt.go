@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("store")
+
+type boltStore struct {
+	db   *bolt.DB
+	stop chan struct{}
+	once sync.Once
+}
+
+func (b *boltStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), encodeBoltValue(value, expiry(ttl)))
+	})
+}
+
+func (b *boltStore) Add(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		if _, expiresAt, ok := decodeBoltValue(bucket.Get([]byte(key))); ok && !expired(expiresAt, time.Now()) {
+			return ErrExists
+		}
+		return bucket.Put([]byte(key), encodeBoltValue(value, expiry(ttl)))
+	})
+}
+
+func (b *boltStore) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get([]byte(key))
+		v, expiresAt, ok := decodeBoltValue(data)
+		if !ok || expired(expiresAt, time.Now()) {
+			return ErrNotFound
+		}
+		value = v
+		return nil
+	})
+
+	return value, err
+}
+
+func (b *boltStore) Consume(ctx context.Context, key string) (string, error) {
+	var value string
+	found := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		data := bucket.Get([]byte(key))
+		v, expiresAt, ok := decodeBoltValue(data)
+		if !ok {
+			return nil
+		}
+		// an expired key is removed too, so the transaction must commit
+		if err := bucket.Delete([]byte(key)); err != nil {
+			return err
+		}
+		if !expired(expiresAt, time.Now()) {
+			value = v
+			found = true
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (b *boltStore) Delete(ctx context.Context, key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+}
+
+func (b *boltStore) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.stop)
+		err = b.db.Close()
+	})
+	return err
+}
+
+// janitor : Periodically remove expired keys from the database
+func (b *boltStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			if err := b.sweep(now); err != nil {
+				log.Warn().Err(err).Msg("Failed to remove expired store keys")
+			}
+		}
+	}
+}
+
+// sweep : Remove the keys expired at now
+func (b *boltStore) sweep(now time.Time) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+
+		// deleting through the cursor skips the next key, so collect them first
+		var keys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			if _, expiresAt, ok := decodeBoltValue(v); !ok || expired(expiresAt, now) {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encodeBoltValue : Prefix the value with its expiry as unix nanoseconds, 0 when it never expires
+func encodeBoltValue(value string, expiresAt time.Time) []byte {
+	data := make([]byte, 8+len(value))
+	if !expiresAt.IsZero() {
+		binary.BigEndian.PutUint64(data, uint64(expiresAt.UnixNano()))
+	}
+	copy(data[8:], value)
+	return data
+}
+
+func decodeBoltValue(data []byte) (string, time.Time, bool) {
+	if len(data) < 8 {
+		return "", time.Time{}, false
+	}
+
+	var expiresAt time.Time
+	if nanos := binary.BigEndian.Uint64(data); nanos != 0 {
+		expiresAt = time.Unix(0, int64(nanos))
+	}
+	return string(data[8:]), expiresAt, true
+}
+
+// NewBoltStore creates a store in an embedded bbolt database file. The file
+// survives restarts but can only be opened by one process at a time.
+func NewBoltStore(path string, cleanupInterval time.Duration) (Store, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create store directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create store bucket: %w", err)
+	}
+
+	b := &boltStore{
+		db:   db,
+		stop: make(chan struct{}),
+	}
+	go b.janitor(cleanupInterval)
+
+	return b, nil
+}
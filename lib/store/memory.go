@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryItem struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+	stop  chan struct{}
+	once  sync.Once
+}
+
+func (m *memoryStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items[key] = memoryItem{value: value, expiresAt: expiry(ttl)}
+	return nil
+}
+
+func (m *memoryStore) Add(ctx context.Context, key string, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if item, ok := m.items[key]; ok && !expired(item.expiresAt, time.Now()) {
+		return ErrExists
+	}
+
+	m.items[key] = memoryItem{value: value, expiresAt: expiry(ttl)}
+	return nil
+}
+
+func (m *memoryStore) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[key]
+	if !ok || expired(item.expiresAt, time.Now()) {
+		return "", ErrNotFound
+	}
+
+	return item.value, nil
+}
+
+func (m *memoryStore) Consume(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	delete(m.items, key)
+
+	if expired(item.expiresAt, time.Now()) {
+		return "", ErrNotFound
+	}
+
+	return item.value, nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.items, key)
+	return nil
+}
+
+func (m *memoryStore) Close() error {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+	return nil
+}
+
+// janitor : Periodically remove expired items so abandoned keys do not leak memory
+func (m *memoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-ticker.C:
+			m.sweep(now)
+		}
+	}
+}
+
+// sweep : Remove the items expired at now
+func (m *memoryStore) sweep(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, item := range m.items {
+		if expired(item.expiresAt, now) {
+			delete(m.items, key)
+		}
+	}
+}
+
+// NewMemoryStore creates an in-process store. Keys are not shared between
+// replicas, use the redis store for that.
+func NewMemoryStore(cleanupInterval time.Duration) Store {
+	m := &memoryStore{
+		items: make(map[string]memoryItem),
+		stop:  make(chan struct{}),
+	}
+	go m.janitor(cleanupInterval)
+
+	return m
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func (r *redisStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, max(ttl, 0)).Err()
+}
+
+func (r *redisStore) Add(ctx context.Context, key string, value string, ttl time.Duration) error {
+	ok, err := r.client.SetNX(ctx, key, value, max(ttl, 0)).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrExists
+	}
+
+	return nil
+}
+
+func (r *redisStore) Get(ctx context.Context, key string) (string, error) {
+	value, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+
+	return value, err
+}
+
+// Consume uses GETDEL so concurrent replicas cannot both consume a key
+func (r *redisStore) Consume(ctx context.Context, key string) (string, error) {
+	value, err := r.client.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+
+	return value, err
+}
+
+func (r *redisStore) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+func (r *redisStore) Close() error {
+	return r.client.Close()
+}
+
+// NewRedisStore creates a store backed by any server speaking the Redis
+// protocol (Redis 6.2+, Valkey, KeyDB, Dragonfly). Expiry is handled by the server.
+func NewRedisStore(redisUrl string) (Store, error) {
+	options, err := redis.ParseURL(redisUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &redisStore{client: client}, nil
+}
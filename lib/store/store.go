@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a key does not exist or has expired
+	ErrNotFound = errors.New("store: key not found")
+	// ErrExists is returned by Add when the key is already set
+	ErrExists = errors.New("store: key exists")
+)
+
+// Store is a key value store with per-key expiry. Implementations are safe
+// for concurrent use.
+type Store interface {
+	// Set stores value under key until ttl elapses, a ttl <= 0 keeps it until deleted
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	// Add sets key like Set only when it does not exist yet, atomically, and returns ErrExists otherwise
+	Add(ctx context.Context, key string, value string, ttl time.Duration) error
+	// Get returns the value of key without removing it
+	Get(ctx context.Context, key string) (string, error)
+	// Consume atomically returns and removes the value of key, so only one caller can use it
+	Consume(ctx context.Context, key string) (string, error)
+	// Delete removes key, it is not an error if it does not exist
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
+
+const (
+	DriverMemory = "memory"
+	DriverBolt   = "bolt"
+	DriverRedis  = "redis"
+)
+
+// Config holds the configuration for creating a store
+type Config struct {
+	Driver          string
+	Path            string
+	RedisUrl        string
+	CleanupInterval time.Duration
+}
+
+// New creates the store selected by config.Driver
+func New(config Config) (Store, error) {
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = time.Minute
+	}
+
+	switch config.Driver {
+	case "", DriverMemory:
+		return NewMemoryStore(config.CleanupInterval), nil
+	case DriverBolt:
+		return NewBoltStore(config.Path, config.CleanupInterval)
+	case DriverRedis:
+		return NewRedisStore(config.RedisUrl)
+	default:
+		return nil, fmt.Errorf("unsupported store driver: %s", config.Driver)
+	}
+}
+
+// expiry : Return the expiry of a ttl, the zero time when it never expires
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(ttl)
+}
+
+// expired : Report whether a key with the given expiry is expired at now
+func expired(expiresAt time.Time, now time.Time) bool {
+	return !expiresAt.IsZero() && now.After(expiresAt)
+}
@@ -0,0 +1,234 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// testStores : Return every store implementation available to the test, redis only when STORE_TEST_REDIS_URL is set
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	// a long cleanup interval keeps the janitors out of the way, sweeps are tested directly
+	stores := map[string]Store{
+		DriverMemory: NewMemoryStore(time.Hour),
+	}
+
+	b, err := NewBoltStore(filepath.Join(t.TempDir(), "store.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores[DriverBolt] = b
+
+	if redisUrl := os.Getenv("STORE_TEST_REDIS_URL"); redisUrl != "" {
+		redis, err := NewRedisStore(redisUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores[DriverRedis] = redis
+	}
+
+	t.Cleanup(func() {
+		for _, s := range stores {
+			s.Close()
+		}
+	})
+
+	return stores
+}
+
+func TestStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wait    time.Duration
+		action  func(s Store, ctx context.Context, key string) (string, error)
+		want    string
+		wantErr error
+		// wantAfter is the error of a Get after the action
+		wantAfter error
+	}{
+		{"get", time.Minute, 0, Store.Get, "value", nil, nil},
+		{"get without ttl", 0, 0, Store.Get, "value", nil, nil},
+		{"get expired", 1100 * time.Millisecond, 1500 * time.Millisecond, Store.Get, "", ErrNotFound, ErrNotFound},
+		{"consume", time.Minute, 0, Store.Consume, "value", nil, ErrNotFound},
+		{"consume expired", 1100 * time.Millisecond, 1500 * time.Millisecond, Store.Consume, "", ErrNotFound, ErrNotFound},
+		{"delete", time.Minute, 0, func(s Store, ctx context.Context, key string) (string, error) {
+			return "", s.Delete(ctx, key)
+		}, "", nil, ErrNotFound},
+		{"missing key", time.Minute, 0, func(s Store, ctx context.Context, key string) (string, error) {
+			return s.Get(ctx, key+"-missing")
+		}, "", ErrNotFound, nil},
+		{"consume missing key", time.Minute, 0, func(s Store, ctx context.Context, key string) (string, error) {
+			return s.Consume(ctx, key+"-missing")
+		}, "", ErrNotFound, nil},
+		{"delete missing key", time.Minute, 0, func(s Store, ctx context.Context, key string) (string, error) {
+			return "", s.Delete(ctx, key+"-missing")
+		}, "", nil, nil},
+	}
+
+	ctx := context.Background()
+	for driver, s := range testStores(t) {
+		for _, tt := range tests {
+			t.Run(driver+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				key := "test:" + tt.name
+				if err := s.Set(ctx, key, "value", tt.ttl); err != nil {
+					t.Fatal(err)
+				}
+				time.Sleep(tt.wait)
+
+				got, err := tt.action(s, ctx, key)
+				if got != tt.want || !errors.Is(err, tt.wantErr) {
+					t.Errorf("got %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+				}
+				if _, err := s.Get(ctx, key); !errors.Is(err, tt.wantAfter) {
+					t.Errorf("Get after = %v, want %v", err, tt.wantAfter)
+				}
+			})
+		}
+	}
+}
+
+func TestConsumeOnce(t *testing.T) {
+	ctx := context.Background()
+
+	for driver, s := range testStores(t) {
+		t.Run(driver, func(t *testing.T) {
+			key := "test:consume-once"
+			if err := s.Set(ctx, key, "value", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			consumed := 0
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := s.Consume(ctx, key); err == nil {
+						mu.Lock()
+						consumed++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			if consumed != 1 {
+				t.Errorf("key consumed %d times, want 1", consumed)
+			}
+		})
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	m := NewMemoryStore(time.Hour).(*memoryStore)
+	defer m.Close()
+
+	ctx := context.Background()
+	m.Set(ctx, "expired", "v", time.Second)
+	m.Set(ctx, "live", "v", time.Hour)
+	m.Set(ctx, "forever", "v", 0)
+
+	m.sweep(time.Now().Add(time.Minute))
+
+	for key, want := range map[string]bool{"expired": false, "live": true, "forever": true} {
+		if _, ok := m.items[key]; ok != want {
+			t.Errorf("%s kept = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestBoltSweep(t *testing.T) {
+	s, err := NewBoltStore(filepath.Join(t.TempDir(), "store.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := s.(*boltStore)
+	defer b.Close()
+
+	// adjacent expired keys, deleting through the cursor would skip every second one
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		b.Set(ctx, key, "v", time.Second)
+	}
+	b.Set(ctx, "f", "v", time.Hour)
+	b.Set(ctx, "g", "v", 0)
+
+	if err := b.sweep(time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	var kept []string
+	b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			kept = append(kept, string(k))
+			return nil
+		})
+	})
+	if len(kept) != 2 || kept[0] != "f" || kept[1] != "g" {
+		t.Errorf("kept keys = %v, want [f g]", kept)
+	}
+}
+
+func TestBoltConsumeRemovesExpired(t *testing.T) {
+	s, err := NewBoltStore(filepath.Join(t.TempDir(), "store.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := s.(*boltStore)
+	defer b.Close()
+
+	ctx := context.Background()
+	b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte("expired"), encodeBoltValue("v", time.Now().Add(-time.Minute)))
+	})
+
+	if _, err := b.Consume(ctx, "expired"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Consume = %v, want ErrNotFound", err)
+	}
+
+	var data []byte
+	b.db.View(func(tx *bolt.Tx) error {
+		data = tx.Bucket(boltBucket).Get([]byte("expired"))
+		return nil
+	})
+	if data != nil {
+		t.Errorf("expired key is still stored")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	ctx := context.Background()
+
+	for driver, s := range testStores(t) {
+		t.Run(driver, func(t *testing.T) {
+			if err := s.Add(ctx, "test:add", "first", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Add(ctx, "test:add", "second", time.Minute); !errors.Is(err, ErrExists) {
+				t.Errorf("second Add = %v, want %v", err, ErrExists)
+			}
+			if value, _ := s.Get(ctx, "test:add"); value != "first" {
+				t.Errorf("value = %q, want the first one", value)
+			}
+
+			// an expired key counts as absent
+			s.Set(ctx, "test:add-expired", "old", time.Second)
+			time.Sleep(1100 * time.Millisecond)
+			if err := s.Add(ctx, "test:add-expired", "new", time.Minute); err != nil {
+				t.Errorf("Add over an expired key = %v", err)
+			}
+		})
+	}
+}